@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+)
+
+// AuthRequest struct
+// authorization request in progress, keep it (e.g. in an encrypted cookie) until the callback
+type AuthRequest struct {
+	URL          string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// AuthCodeURL build authorization url with random state, S256 PKCE challenge and nonce when openid is requested,
+// params are added to the url, e.g. prompt=consent
+func (c *Client) AuthCodeURL(params url.Values) (*AuthRequest, error) {
+	state, err := appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	codeVerifier, err := appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	authRequest := &AuthRequest{State: state, CodeVerifier: codeVerifier}
+
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+
+	query.Set("response_type", "code")
+	query.Set("client_id", c.config.ClientID)
+	query.Set("state", state)
+	query.Set("code_challenge", appSecurity.S256CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", appSecurity.CodeChallengeMethodS256)
+	if len(c.config.RedirectURL) > 0 {
+		query.Set("redirect_uri", c.config.RedirectURL)
+	}
+
+	if len(c.config.Scopes) > 0 {
+		query.Set("scope", strings.Join(c.config.Scopes, " "))
+	}
+
+	if c.hasScope("openid") {
+		authRequest.Nonce, err = appSecurity.GenerateRandomToken(16)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("nonce", authRequest.Nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(c.config.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	authRequest.URL = c.config.AuthorizationEndpoint + separator + query.Encode()
+	return authRequest, nil
+}
+
+// HandleCallback check state of redirect uri request and exchange its code,
+// error sent by the authorization endpoint is returned as *Error.
+// id_token is verified when it is returned, and must be returned when the request has a nonce
+func (c *Client) HandleCallback(ctx context.Context, req *http.Request, authRequest *AuthRequest) (*Token, error) {
+	query := req.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(authRequest.State)) != 1 {
+		return nil, ErrStateMismatch
+	}
+
+	if code := query.Get("error"); len(code) > 0 {
+		return nil, &Error{Code: code, Description: query.Get("error_description"), URI: query.Get("error_uri")}
+	}
+
+	if len(query.Get("code")) <= 0 {
+		return nil, &Error{Code: ErrorInvalidRequest, Description: "code is missing"}
+	}
+
+	token, err := c.Exchange(ctx, query.Get("code"), authRequest.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(token.IDToken) <= 0 {
+		if len(authRequest.Nonce) > 0 {
+			return nil, ErrNoIDToken
+		}
+
+		return token, nil
+	}
+
+	if _, err := c.VerifyIDToken(ctx, token.IDToken, authRequest.Nonce); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,186 @@
+// Package client is the Go client of an oauth2-go server.
+// It builds authorization urls with state, nonce and PKCE, exchanges codes and verifies the id_token,
+// runs client credentials, refreshes tokens behind an http.RoundTripper and returns token endpoint errors as *Error
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	discoveryModel "github.com/musobarlab/oauth2-go/core/discovery/model"
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// Config struct
+// endpoints left empty are discovered from Issuer, AuthMethod is client_secret_basic by default
+// and none for client without secret, RedirectURL must be registered for the client.
+// Issuer and JWKSURI are required to verify the id_token when openid is in Scopes
+type Config struct {
+	Issuer                string
+	ClientID              string
+	ClientSecret          string
+	AuthMethod            string
+	RedirectURL           string
+	Scopes                []string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	RevocationEndpoint    string
+	JWKSURI               string
+	HTTPClient            *http.Client
+}
+
+// Client struct
+type Client struct {
+	config Config
+	client *http.Client
+
+	mutex sync.Mutex
+	jwks  *key.JWKS
+}
+
+// New function for initializing Client, discovery metadata is fetched when an endpoint is not configured
+func New(ctx context.Context, config Config) (*Client, error) {
+	if len(config.ClientID) <= 0 {
+		return nil, errors.New("client id is required")
+	}
+
+	if len(config.AuthMethod) <= 0 {
+		config.AuthMethod = appModel.AuthMethodClientSecretBasic
+		if len(config.ClientSecret) <= 0 {
+			config.AuthMethod = appModel.AuthMethodNone
+		}
+	}
+
+	switch config.AuthMethod {
+	case appModel.AuthMethodClientSecretBasic, appModel.AuthMethodClientSecretPost, appModel.AuthMethodNone:
+	default:
+		return nil, fmt.Errorf("auth method %s is not supported", config.AuthMethod)
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	c := &Client{config: config, client: client}
+	openID := c.hasScope("openid")
+	if len(config.AuthorizationEndpoint) <= 0 || len(config.TokenEndpoint) <= 0 || (openID && len(config.JWKSURI) <= 0) {
+		if err := c.discover(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if openID && (len(c.config.Issuer) <= 0 || len(c.config.JWKSURI) <= 0) {
+		return nil, errors.New("issuer and jwks uri are required to verify id_token")
+	}
+
+	return c, nil
+}
+
+// Config return configuration with discovered endpoints
+func (c *Client) Config() Config {
+	return c.config
+}
+
+// discover fill missing endpoints from issuer metadata
+func (c *Client) discover(ctx context.Context) error {
+	issuer := c.config.Issuer
+	if len(issuer) <= 0 {
+		return errors.New("issuer is required when endpoints are not configured")
+	}
+
+	var metadata discoveryModel.Metadata
+	if err := c.getJSON(ctx, issuer+"/.well-known/oauth-authorization-server", &metadata); err != nil {
+		return err
+	}
+
+	if metadata.Issuer != issuer {
+		return errors.New("issuer in discovery document does not match")
+	}
+
+	if len(c.config.AuthorizationEndpoint) <= 0 {
+		c.config.AuthorizationEndpoint = metadata.AuthorizationEndpoint
+	}
+
+	if len(c.config.TokenEndpoint) <= 0 {
+		c.config.TokenEndpoint = metadata.TokenEndpoint
+	}
+
+	if len(c.config.RevocationEndpoint) <= 0 {
+		c.config.RevocationEndpoint = metadata.RevocationEndpoint
+	}
+
+	if len(c.config.JWKSURI) <= 0 {
+		c.config.JWKSURI = metadata.JWKSURI
+	}
+
+	return nil
+}
+
+// hasScope report whether scope is in Config.Scopes
+func (c *Client) hasScope(scope string) bool {
+	for _, s := range c.config.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getJSON function
+func (c *Client) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: unexpected status %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// postForm send form encoded request authenticated with the configured client auth method, RFC 6749 section 2.3.1
+func (c *Client) postForm(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	switch c.config.AuthMethod {
+	case appModel.AuthMethodClientSecretPost:
+		params.Set("client_id", c.config.ClientID)
+		params.Set("client_secret", c.config.ClientSecret)
+	case appModel.AuthMethodNone:
+		params.Set("client_id", c.config.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.AuthMethod == appModel.AuthMethodClientSecretBasic {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	return c.client.Do(req.WithContext(ctx))
+}
@@ -0,0 +1,393 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/client"
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	"github.com/musobarlab/oauth2-go/internal/oauth2test"
+)
+
+const (
+	testRedirectURL = "http://localhost:8000/callback"
+	testUserID      = "user-1"
+	testEmail       = "user-1@example.com"
+	testPassword    = "password-1"
+)
+
+// fixture authorization server with a registered client and a logged in user
+type fixture struct {
+	*oauth2test.Server
+
+	client      *client.Client
+	information *appModel.ClientInformation
+	browser     *oauth2test.Browser
+}
+
+func newFixture(t *testing.T) *fixture {
+	s := oauth2test.NewServer(t)
+
+	information := s.RegisterClient(t, appModel.ClientMetadata{
+		ClientName:              "Report Dashboard",
+		RedirectURIs:            []string{testRedirectURL},
+		TokenEndpointAuthMethod: "client_secret_basic",
+		GrantTypes:              []string{"authorization_code", "refresh_token", "client_credentials"},
+		Scope:                   "openid report.read",
+	})
+
+	s.CreateUser(t, testUserID, testEmail, testPassword)
+	browser := s.NewBrowser(t)
+	browser.Login(testEmail, testPassword)
+
+	f := &fixture{Server: s, information: information, browser: browser}
+	f.client = f.newClient(t, information.ClientID, information.ClientSecret)
+	return f
+}
+
+func (f *fixture) newClient(t *testing.T, clientID, clientSecret string) *client.Client {
+	c, err := client.New(context.Background(), client.Config{
+		Issuer:       f.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "report.read"},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	return c
+}
+
+// authorize send the user through the authorization endpoint, return the redirect uri request
+func (f *fixture) authorize(authRequest *client.AuthRequest) *http.Request {
+	return httptest.NewRequest(http.MethodGet, f.browser.Authorize(authRequest.URL), nil)
+}
+
+// login run the authorization code flow, return the token issued
+func (f *fixture) login(t *testing.T) (*client.Token, *client.AuthRequest) {
+	authRequest, err := f.client.AuthCodeURL(nil)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	token, err := f.client.HandleCallback(context.Background(), f.authorize(authRequest), authRequest)
+	if err != nil {
+		t.Fatalf("handle callback: %v", err)
+	}
+
+	return token, authRequest
+}
+
+func TestNewDiscoversEndpoints(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	config := f.client.Config()
+	if config.AuthorizationEndpoint != f.URL+"/get_authorize_user" || config.TokenEndpoint != f.URL+"/api/oauth2/token" ||
+		config.RevocationEndpoint != f.URL+"/api/oauth2/revoke" || config.JWKSURI != f.URL+"/.well-known/jwks.json" {
+		t.Errorf("config = %+v", config)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	authRequest, err := f.client.AuthCodeURL(url.Values{"prompt": {"consent"}})
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	u, err := url.Parse(authRequest.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+
+	query := u.Query()
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             f.information.ClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid report.read",
+		"state":                 authRequest.State,
+		"nonce":                 authRequest.Nonce,
+		"code_challenge":        appSecurity.S256CodeChallenge(authRequest.CodeVerifier),
+		"code_challenge_method": "S256",
+		"prompt":                "consent",
+	}
+
+	for k, v := range expected {
+		if query.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, query.Get(k), v)
+		}
+	}
+
+	if u.Scheme+"://"+u.Host+u.Path != f.URL+"/get_authorize_user" {
+		t.Errorf("authorization endpoint = %q", authRequest.URL)
+	}
+
+	if len(authRequest.State) <= 0 || len(authRequest.Nonce) <= 0 || len(authRequest.CodeVerifier) <= 0 {
+		t.Errorf("auth request = %+v", authRequest)
+	}
+
+	other, err := f.client.AuthCodeURL(nil)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	if other.State == authRequest.State || other.CodeVerifier == authRequest.CodeVerifier {
+		t.Error("state and code verifier must be random for every request")
+	}
+}
+
+func TestHandleCallbackExchangesCodeWithPKCE(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	token, authRequest := f.login(t)
+	if !token.Valid() || token.TokenType != "Bearer" || len(token.RefreshToken) <= 0 || len(token.IDToken) <= 0 {
+		t.Errorf("token = %+v", token)
+	}
+
+	if len(token.Scopes()) != 2 {
+		t.Errorf("scopes = %v", token.Scopes())
+	}
+
+	claims, err := f.client.VerifyIDToken(context.Background(), token.IDToken, authRequest.Nonce)
+	if err != nil {
+		t.Fatalf("verify id_token: %v", err)
+	}
+
+	if claims.Subject != testUserID || claims.Audience != f.information.ClientID || claims.AuthTime <= 0 {
+		t.Errorf("id_token claims = %+v", claims)
+	}
+}
+
+func TestHandleCallbackRejectsWrongCodeVerifier(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	authRequest, err := f.client.AuthCodeURL(nil)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	callback := f.authorize(authRequest)
+	authRequest.CodeVerifier = "another-verifier"
+
+	_, err = f.client.HandleCallback(context.Background(), callback, authRequest)
+	if !client.IsCode(err, client.ErrorInvalidGrant) {
+		t.Fatalf("err = %v, want invalid_grant", err)
+	}
+
+	if oauth2Err := err.(*client.Error); oauth2Err.StatusCode != http.StatusBadRequest || oauth2Err.Description != "invalid code verifier" {
+		t.Errorf("err = %#v", oauth2Err)
+	}
+}
+
+func TestHandleCallbackChecksState(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	authRequest, err := f.client.AuthCodeURL(nil)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	callback := httptest.NewRequest(http.MethodGet, testRedirectURL+"?code=code-1&state=forged", nil)
+	if _, err := f.client.HandleCallback(context.Background(), callback, authRequest); err != client.ErrStateMismatch {
+		t.Errorf("err = %v, want ErrStateMismatch", err)
+	}
+
+	// prompt=none without a stored consent is answered with consent_required on the redirect uri
+	authRequest, err = f.client.AuthCodeURL(url.Values{"prompt": {"none"}})
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	_, err = f.client.HandleCallback(context.Background(), f.authorize(authRequest), authRequest)
+	oauth2Err, ok := err.(*client.Error)
+	if !ok || oauth2Err.Code != client.ErrorConsentRequired || oauth2Err.StatusCode != 0 {
+		t.Errorf("err = %#v, want consent_required from the redirect uri", err)
+	}
+}
+
+func TestHandleCallbackVerifiesIDToken(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	authRequest, err := f.client.AuthCodeURL(nil)
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+
+	callback := f.authorize(authRequest)
+	authRequest.Nonce = "another-nonce"
+	if _, err := f.client.HandleCallback(context.Background(), callback, authRequest); err != client.ErrNonceMismatch {
+		t.Errorf("err = %v, want ErrNonceMismatch", err)
+	}
+
+	token, authRequest := f.login(t)
+	other := f.newClient(t, "client-2", "secret-2")
+	if _, err := other.VerifyIDToken(context.Background(), token.IDToken, authRequest.Nonce); err == nil {
+		t.Error("id_token issued to another client was accepted")
+	}
+}
+
+func TestVerifyIDTokenRejectsAccessToken(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	token, _ := f.login(t)
+	if _, err := f.client.VerifyIDToken(context.Background(), token.AccessToken, ""); err == nil {
+		t.Error("access token was accepted as id_token")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	token, _ := f.login(t)
+
+	refreshed, err := f.client.Refresh(context.Background(), token.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if refreshed.AccessToken == token.AccessToken || refreshed.RefreshToken == token.RefreshToken || len(refreshed.RefreshToken) <= 0 {
+		t.Errorf("refresh token was not rotated: %+v", refreshed)
+	}
+
+	_, err = f.client.Refresh(context.Background(), token.RefreshToken)
+	if !client.IsCode(err, client.ErrorInvalidGrant) {
+		t.Errorf("err = %v, want invalid_grant for rotated refresh token", err)
+	}
+
+	// reuse of a rotated refresh token revokes the whole family
+	_, err = f.client.Refresh(context.Background(), refreshed.RefreshToken)
+	if !client.IsCode(err, client.ErrorInvalidGrant) {
+		t.Errorf("err = %v, want invalid_grant after refresh token reuse", err)
+	}
+}
+
+func TestTransportRefreshesExpiredToken(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	token, _ := f.login(t)
+	token.Expiry = time.Now().Add(-time.Minute)
+
+	var changed []*client.Token
+	httpClient := client.NewHTTPClient(f.client.TokenSource(token, func(token *client.Token) {
+		changed = append(changed, token)
+	}))
+
+	for n := 0; n < 2; n++ {
+		res, err := httpClient.Get(f.URL + "/userinfo")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("userinfo status = %d", res.StatusCode)
+		}
+
+		if len(changed) != 1 {
+			t.Fatalf("token changed %d times, want 1", len(changed))
+		}
+	}
+
+	// one exchange of the code by login, one refresh by the transport
+	if f.Requests("/api/oauth2/token") != 2 {
+		t.Errorf("token endpoint called %d times, want 2", f.Requests("/api/oauth2/token"))
+	}
+
+	if changed[0].AccessToken == token.AccessToken || changed[0].RefreshToken == token.RefreshToken {
+		t.Error("refreshed token was not passed to onChange")
+	}
+}
+
+func TestTransportWithoutRefreshToken(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	expired := &client.Token{AccessToken: "access-1", Expiry: time.Now().Add(-time.Minute)}
+
+	_, err := client.NewHTTPClient(f.client.TokenSource(expired, nil)).Get(f.URL + "/userinfo")
+	if err == nil {
+		t.Fatal("request with expired token was sent")
+	}
+
+	if urlErr, ok := err.(*url.Error); !ok || urlErr.Err != client.ErrNoRefreshToken {
+		t.Errorf("err = %v, want ErrNoRefreshToken", err)
+	}
+
+	if f.Requests("/userinfo") != 0 {
+		t.Errorf("userinfo called %d times, want 0", f.Requests("/userinfo"))
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	token, err := f.client.ClientCredentials(context.Background(), "report.read")
+	if err != nil {
+		t.Fatalf("client credentials: %v", err)
+	}
+
+	if !token.Valid() || len(token.RefreshToken) > 0 || token.Scope != "report.read" {
+		t.Errorf("token = %+v", token)
+	}
+}
+
+func TestTokenEndpointErrors(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	_, err := f.newClient(t, f.information.ClientID, "wrong-secret").ClientCredentials(context.Background())
+	oauth2Err, ok := err.(*client.Error)
+	if !ok {
+		t.Fatalf("err = %T %v, want *client.Error", err, err)
+	}
+
+	if oauth2Err.StatusCode != http.StatusUnauthorized || oauth2Err.Code != client.ErrorInvalidClient || oauth2Err.Description != "client authentication failed" {
+		t.Errorf("err = %#v", oauth2Err)
+	}
+
+	if oauth2Err.Error() != "oauth2: invalid_client: client authentication failed" {
+		t.Errorf("message = %q", oauth2Err.Error())
+	}
+}
+
+func TestTokenEndpointErrorWithoutJSON(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		http.Error(res, "bad gateway", http.StatusBadGateway)
+	}))
+	defer bad.Close()
+
+	c, err := client.New(context.Background(), client.Config{
+		ClientID:              "client-1",
+		ClientSecret:          "secret-1",
+		AuthorizationEndpoint: bad.URL + "/authorize",
+		TokenEndpoint:         bad.URL + "/token",
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	_, err = c.ClientCredentials(context.Background())
+	oauth2Err, ok := err.(*client.Error)
+	if !ok || oauth2Err.StatusCode != http.StatusBadGateway || oauth2Err.Code != client.ErrorServerError {
+		t.Errorf("err = %#v, want server_error with status 502", err)
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// OAuth2 error codes, RFC 6749 section 4.1.2.1 and 5.2
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorServerError             = "server_error"
+	ErrorConsentRequired         = "consent_required"
+)
+
+var (
+	// ErrStateMismatch returned when callback state is not the state of the authorization request
+	ErrStateMismatch = errors.New("state does not match the authorization request")
+
+	// ErrNoRefreshToken returned when an expired token can not be refreshed
+	ErrNoRefreshToken = errors.New("token expired and has no refresh token")
+
+	// ErrNoIDToken returned when openid was requested but the token response has no id_token
+	ErrNoIDToken = errors.New("token response has no id_token")
+
+	// ErrNonceMismatch returned when id_token nonce is not the nonce of the authorization request
+	ErrNonceMismatch = errors.New("id_token nonce does not match the authorization request")
+)
+
+// Error struct
+// error response of the server, StatusCode is 0 for errors sent to the redirect uri
+type Error struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+}
+
+// Error function
+func (e *Error) Error() string {
+	if len(e.Description) > 0 {
+		return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+	}
+
+	return "oauth2: " + e.Code
+}
+
+// IsCode return true when err is *Error with code
+func IsCode(err error, code string) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == code
+}
+
+// parseError read error response, body that is not RFC 6749 json is reported with the status only
+func parseError(res *http.Response) error {
+	e := &Error{StatusCode: res.StatusCode}
+	if err := json.NewDecoder(res.Body).Decode(e); err != nil || len(e.Code) <= 0 {
+		e.Code = ErrorServerError
+		e.Description = fmt.Sprintf("unexpected status %d", res.StatusCode)
+	}
+
+	return e
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// IDTokenClaims struct
+// claims of OpenID Connect id_token, user claims released by profile and email scopes are not parsed
+type IDTokenClaims struct {
+	jwt.StandardClaims
+	Nonce    string `json:"nonce,omitempty"`
+	AuthTime int64  `json:"auth_time,omitempty"`
+}
+
+// VerifyIDToken check id_token signature with the issuer keys, its issuer, audience and expiry,
+// OpenID Connect Core 1.0 section 3.1.3.7. nonce is AuthRequest.Nonce and must match when it is not empty
+func (c *Client) VerifyIDToken(ctx context.Context, idToken, nonce string) (*IDTokenClaims, error) {
+	if len(c.config.Issuer) <= 0 || len(c.config.JWKSURI) <= 0 {
+		return nil, errors.New("issuer and jwks uri are required to verify id_token")
+	}
+
+	keyFunc := jwtGen.KeyFunc(&contextKeys{ctx: ctx, client: c})
+	token, err := jwt.ParseWithClaims(idToken, &IDTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// access token is signed with the same keys, it must not pass as id_token
+		typ, _ := token.Header["typ"].(string)
+		if strings.TrimPrefix(strings.ToLower(typ), "application/") == jwtGen.AccessTokenType {
+			return nil, errors.New("token is not an id_token")
+		}
+
+		return keyFunc(token)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*IDTokenClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("id_token is not valid")
+	}
+
+	if claims.ExpiresAt <= 0 {
+		return nil, errors.New("id_token has no expiry")
+	}
+
+	if claims.Issuer != c.config.Issuer {
+		return nil, errors.New("id_token issuer does not match")
+	}
+
+	if claims.Audience != c.config.ClientID {
+		return nil, errors.New("id_token audience does not match")
+	}
+
+	if len(nonce) > 0 && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrNonceMismatch
+	}
+
+	return claims, nil
+}
+
+// publicKey look kid up in cached JWKS, JWKS is fetched again when it does not know kid, e.g. after key rotation
+func (c *Client) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.jwks != nil {
+		if jwk, err := c.jwks.Key(kid); err == nil {
+			return jwk.PublicKey()
+		}
+	}
+
+	var jwks key.JWKS
+	if err := c.getJSON(ctx, c.config.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	c.jwks = &jwks
+
+	jwk, err := jwks.Key(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.PublicKey()
+}
+
+// contextKeys key.PublicKeyProvider fetching keys with ctx of the id_token being verified
+type contextKeys struct {
+	ctx    context.Context
+	client *Client
+}
+
+// PublicKey function
+func (k *contextKeys) PublicKey(kid string) (crypto.PublicKey, error) {
+	return k.client.publicKey(k.ctx, kid)
+}
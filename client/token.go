@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+)
+
+// expiryDelta token is treated as expired a bit early, so it does not expire on the way to the api
+const expiryDelta = 10 * time.Second
+
+// Token struct
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	IDToken      string
+	Scope        string
+	Expiry       time.Time
+}
+
+// Valid return true when token has an access token that has not expired
+func (t *Token) Valid() bool {
+	if t == nil || len(t.AccessToken) <= 0 {
+		return false
+	}
+
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// Scopes return granted scopes
+func (t *Token) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// Exchange exchange authorization code for token, codeVerifier is AuthRequest.CodeVerifier.
+// id_token is not verified, use HandleCallback or VerifyIDToken with AuthRequest.Nonce
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	if len(c.config.RedirectURL) > 0 {
+		params.Set("redirect_uri", c.config.RedirectURL)
+	}
+
+	if len(codeVerifier) > 0 {
+		params.Set("code_verifier", codeVerifier)
+	}
+
+	return c.tokenRequest(ctx, params)
+}
+
+// Refresh get new token with refresh token, scopes narrow the new access token when given
+func (c *Client) Refresh(ctx context.Context, refreshToken string, scopes ...string) (*Token, error) {
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", refreshToken)
+	if len(scopes) > 0 {
+		params.Set("scope", strings.Join(scopes, " "))
+	}
+
+	token, err := c.tokenRequest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// server may keep the refresh token, RFC 6749 section 6
+	if len(token.RefreshToken) <= 0 {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+// ClientCredentials get token for the client itself, Config.Scopes are requested when scopes is empty
+func (c *Client) ClientCredentials(ctx context.Context, scopes ...string) (*Token, error) {
+	if len(scopes) <= 0 {
+		scopes = c.config.Scopes
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "client_credentials")
+	if len(scopes) > 0 {
+		params.Set("scope", strings.Join(scopes, " "))
+	}
+
+	return c.tokenRequest(ctx, params)
+}
+
+// Revoke revoke refresh token or access token, RFC 7009
+func (c *Client) Revoke(ctx context.Context, token, tokenTypeHint string) error {
+	if len(c.config.RevocationEndpoint) <= 0 {
+		return errors.New("server has no revocation endpoint")
+	}
+
+	params := url.Values{}
+	params.Set("token", token)
+	if len(tokenTypeHint) > 0 {
+		params.Set("token_type_hint", tokenTypeHint)
+	}
+
+	res, err := c.postForm(ctx, c.config.RevocationEndpoint, params)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return parseError(res)
+	}
+
+	return nil
+}
+
+// tokenRequest call token endpoint and parse the response
+func (c *Client) tokenRequest(ctx context.Context, params url.Values) (*Token, error) {
+	res, err := c.postForm(ctx, c.config.TokenEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, parseError(res)
+	}
+
+	var payload tokenModel.TokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	if len(payload.AccessToken) <= 0 {
+		return nil, &Error{StatusCode: res.StatusCode, Code: ErrorServerError, Description: "token response has no access token"}
+	}
+
+	token := &Token{
+		AccessToken:  payload.AccessToken,
+		TokenType:    payload.TokenType,
+		RefreshToken: payload.RefreshToken,
+		IDToken:      payload.IDToken,
+		Scope:        payload.Scope,
+	}
+
+	if payload.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// TokenSource interface
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// refreshTokenSource struct
+// hold token of a user and refresh it when expired, rotated refresh token replaces the old one
+type refreshTokenSource struct {
+	sync.Mutex
+	client   *Client
+	token    *Token
+	onChange func(*Token)
+}
+
+// TokenSource return TokenSource that refresh token when it expires,
+// onChange is called with every new token, e.g. to store the rotated refresh token, and may be nil
+func (c *Client) TokenSource(token *Token, onChange func(*Token)) TokenSource {
+	return &refreshTokenSource{client: c, token: token, onChange: onChange}
+}
+
+// Token function
+func (s *refreshTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	if s.token == nil || len(s.token.RefreshToken) <= 0 {
+		return nil, ErrNoRefreshToken
+	}
+
+	token, err := s.client.Refresh(ctx, s.token.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+	if s.onChange != nil {
+		s.onChange(token)
+	}
+
+	return token, nil
+}
+
+// clientCredentialsTokenSource struct
+type clientCredentialsTokenSource struct {
+	sync.Mutex
+	client *Client
+	scopes []string
+	token  *Token
+}
+
+// ClientCredentialsTokenSource return TokenSource that get a new client credentials token when it expires
+func (c *Client) ClientCredentialsTokenSource(scopes ...string) TokenSource {
+	return &clientCredentialsTokenSource{client: c, scopes: scopes}
+}
+
+// Token function
+func (s *clientCredentialsTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	token, err := s.client.ClientCredentials(ctx, s.scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+	return token, nil
+}
+
+// Transport struct
+// http.RoundTripper that send token of Source as bearer token, Base is http.DefaultTransport when nil
+type Transport struct {
+	Source TokenSource
+	Base   http.RoundTripper
+}
+
+// RoundTrip function
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Source == nil {
+		return nil, errors.New("transport has no token source")
+	}
+
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	// RoundTripper must not modify the request
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	r.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(r)
+}
+
+// NewHTTPClient return http.Client that authorize every request with token of source
+func NewHTTPClient(source TokenSource) *http.Client {
+	return &http.Client{Transport: &Transport{Source: source}}
+}
@@ -0,0 +1,30 @@
+package key
+
+import (
+	"crypto"
+	"io/ioutil"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// InitPrivateKeyFromFile return crypto.Signer parsed from PEM file at path
+// support PKCS#1 RSA, SEC 1 EC and PKCS#8 (RSA, EC P-256, Ed25519) private keys
+func InitPrivateKeyFromFile(path string) (crypto.Signer, error) {
+	signBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.ParsePrivateKeyFromPEM(signBytes)
+}
+
+// InitPublicKeyFromFile return crypto.PublicKey parsed from PEM file at path
+// support PKIX (RSA, EC P-256, Ed25519) and PKCS#1 RSA public keys
+func InitPublicKeyFromFile(path string) (crypto.PublicKey, error) {
+	verifyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.ParsePublicKeyFromPEM(verifyBytes)
+}
@@ -0,0 +1,31 @@
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/musobarlab/oauth2-go/core/scope/model"
+)
+
+// InitScopesFromFile return scopes defined in json file at path
+// [{"name": "report.read", "description": "Read your reports", "default": false, "claims": []}]
+func InitScopesFromFile(path string) ([]*model.Scope, error) {
+	scopeBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes []*model.Scope
+	if err := json.Unmarshal(scopeBytes, &scopes); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		if !model.IsValidName(scope.Name) {
+			return nil, fmt.Errorf("invalid scope name %q", scope.Name)
+		}
+	}
+
+	return scopes, nil
+}
@@ -0,0 +1,179 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// clientAssertionTypeJWTBearer client_assertion_type of jwt client assertion, RFC 7523 section 2.2
+const clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// authenticateClientAssertion authenticate client with signed jwt, RFC 7523 section 3
+// iss and sub must be the client_id, aud the token endpoint or issuer, exp is required
+// and jti is accepted once until the assertion expires
+func (h *Handler) authenticateClientAssertion(credentials clientCredentials) (*appModel.Application, error) {
+	if credentials.clientAssertionType != clientAssertionTypeJWTBearer || len(credentials.clientAssertion) <= 0 {
+		return nil, errClientAuthentication
+	}
+
+	var app *appModel.Application
+	token, err := jwt.Parse(credentials.clientAssertion, func(token *jwt.Token) (interface{}, error) {
+		claims, _ := token.Claims.(jwt.MapClaims)
+		issuer, _ := claims["iss"].(string)
+		subject, _ := claims["sub"].(string)
+		if len(issuer) <= 0 || issuer != subject {
+			return nil, errClientAuthentication
+		}
+
+		// client_id parameter is optional with assertion but must match when sent
+		if len(credentials.clientID) > 0 && credentials.clientID != issuer {
+			return nil, errClientAuthentication
+		}
+
+		outputApp := h.AppRepo.FindByID(issuer)
+		if outputApp.Error != nil {
+			return nil, errClientAuthentication
+		}
+
+		app = outputApp.Result.(*appModel.Application)
+		return assertionKey(app, token)
+	})
+
+	if err != nil || !token.Valid {
+		return nil, errClientAuthentication
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if !h.isAssertionAudience(claims["aud"]) {
+		return nil, errClientAuthentication
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errClientAuthentication
+	}
+
+	jti, _ := claims["jti"].(string)
+	if len(jti) <= 0 {
+		return nil, errClientAuthentication
+	}
+
+	replayID := app.ClientID + ":" + jti
+	if h.ClientAssertionReplay.IsRevoked(replayID) {
+		return nil, errClientAuthentication
+	}
+
+	h.ClientAssertionReplay.Revoke(replayID, time.Unix(int64(exp), 0))
+	return app, nil
+}
+
+// assertionKey select key verifying client assertion by the registered authentication method,
+// private_key_jwt is verified with client public key, client_secret_jwt with HMAC of client secret
+func assertionKey(app *appModel.Application, token *jwt.Token) (interface{}, error) {
+	switch app.TokenEndpointAuthMethod {
+	case appModel.AuthMethodPrivateKeyJWT:
+		alg := token.Method.Alg()
+		if !key.IsSupportedAlgorithm(alg) {
+			return nil, errClientAuthentication
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		pub, err := app.VerificationKey(kid)
+		if err != nil || !key.IsCompatible(pub, alg) {
+			return nil, errClientAuthentication
+		}
+
+		return pub, nil
+	case appModel.AuthMethodClientSecretJWT:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(app.ClientSecret) <= 0 {
+			return nil, errClientAuthentication
+		}
+
+		return []byte(app.ClientSecret), nil
+	}
+
+	return nil, errClientAuthentication
+}
+
+// isAssertionAudience report whether aud claim, string or array, names this server
+func (h *Handler) isAssertionAudience(aud interface{}) bool {
+	var audiences []string
+	switch a := aud.(type) {
+	case string:
+		audiences = []string{a}
+	case []interface{}:
+		for _, v := range a {
+			if s, ok := v.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+
+	for _, a := range audiences {
+		if (len(h.TokenEndpoint) > 0 && a == h.TokenEndpoint) || a == h.Issuer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// registeredKeys validate keys registered for private_key_jwt, either JWKS json or PEM encoded public key,
+// other authentication methods do not register keys
+func registeredKeys(authMethod, jwksValue, publicKeyValue string) (*key.JWKS, string, error) {
+	switch authMethod {
+	case appModel.AuthMethodClientSecretBasic, appModel.AuthMethodClientSecretPost, appModel.AuthMethodClientSecretJWT, appModel.AuthMethodNone:
+		return nil, "", nil
+	case appModel.AuthMethodPrivateKeyJWT:
+	default:
+		return nil, "", errors.New("token endpoint auth method is not supported")
+	}
+
+	jwksValue = strings.TrimSpace(jwksValue)
+	publicKeyValue = strings.TrimSpace(publicKeyValue)
+
+	if len(jwksValue) > 0 {
+		var jwks key.JWKS
+		if err := json.Unmarshal([]byte(jwksValue), &jwks); err != nil {
+			return nil, "", errors.New("invalid jwks")
+		}
+
+		if err := validateJWKS(&jwks); err != nil {
+			return nil, "", err
+		}
+
+		return &jwks, "", nil
+	}
+
+	if len(publicKeyValue) > 0 {
+		if _, err := key.ParsePublicKeyFromPEM([]byte(publicKeyValue)); err != nil {
+			return nil, "", errors.New("invalid public key")
+		}
+
+		return nil, publicKeyValue, nil
+	}
+
+	return nil, "", errors.New("jwks or public key is required for private_key_jwt")
+}
+
+// validateJWKS check every key of jwks registered for private_key_jwt can be used for verification
+func validateJWKS(jwks *key.JWKS) error {
+	if jwks == nil || len(jwks.Keys) <= 0 {
+		return errors.New("jwks is required for private_key_jwt")
+	}
+
+	for _, jwk := range jwks.Keys {
+		if _, err := jwk.PublicKey(); err != nil {
+			return errors.New("invalid jwks")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+)
+
+var errClientAuthentication = errors.New("client authentication failed")
+
+// clientCredentials data structure
+// client authentication parameters sent in request body
+type clientCredentials struct {
+	clientID            string
+	clientSecret        string
+	clientAssertionType string
+	clientAssertion     string
+}
+
+// authenticateClient authenticate client of form encoded request,
+// public client is identified by client_id only when allowPublic is true
+func (h *Handler) authenticateClient(req *http.Request, allowPublic bool) (*appModel.Application, error) {
+	return h.authenticateClientWith(req, clientCredentials{
+		clientID:            req.PostFormValue("client_id"),
+		clientSecret:        req.PostFormValue("client_secret"),
+		clientAssertionType: req.PostFormValue("client_assertion_type"),
+		clientAssertion:     req.PostFormValue("client_assertion"),
+	}, allowPublic)
+}
+
+// authenticateClientWith authenticate client from HTTP Basic header (client_secret_basic),
+// from client_id and client_secret request parameters (client_secret_post), RFC 6749 section 2.3.1,
+// or from jwt client assertion (private_key_jwt, client_secret_jwt), RFC 7523 section 2.2,
+// client must not use more than one method in the same request
+func (h *Handler) authenticateClientWith(req *http.Request, credentials clientCredentials, allowPublic bool) (*appModel.Application, error) {
+	basicID, basicSecret, ok := req.BasicAuth()
+
+	if len(credentials.clientAssertionType) > 0 || len(credentials.clientAssertion) > 0 {
+		if ok || len(credentials.clientSecret) > 0 {
+			return nil, errClientAuthentication
+		}
+
+		return h.authenticateClientAssertion(credentials)
+	}
+
+	clientID := credentials.clientID
+	clientSecret := credentials.clientSecret
+	if ok {
+		if len(clientSecret) > 0 {
+			return nil, errClientAuthentication
+		}
+
+		var err error
+		if basicID, err = url.QueryUnescape(basicID); err != nil {
+			return nil, errClientAuthentication
+		}
+
+		if basicSecret, err = url.QueryUnescape(basicSecret); err != nil {
+			return nil, errClientAuthentication
+		}
+
+		// client_id parameter is optional with basic auth but must match when sent
+		if len(clientID) > 0 && clientID != basicID {
+			return nil, errClientAuthentication
+		}
+
+		clientID = basicID
+		clientSecret = basicSecret
+	}
+
+	if len(clientID) <= 0 {
+		return nil, errClientAuthentication
+	}
+
+	outputApp := h.AppRepo.FindByID(clientID)
+	if outputApp.Error != nil {
+		return nil, errClientAuthentication
+	}
+
+	app := outputApp.Result.(*appModel.Application)
+	if app.IsJWTAuthentication() {
+		return nil, errClientAuthentication
+	}
+
+	if (app.IsPublic() && !allowPublic) || !isValidClient(app, clientSecret) {
+		return nil, errClientAuthentication
+	}
+
+	return app, nil
+}
+
+// isValidClient authenticate client app, public client has no secret to check
+func isValidClient(app *appModel.Application, clientSecret string) bool {
+	if app.IsPublic() {
+		return len(clientSecret) <= 0
+	}
+
+	return len(clientSecret) > 0 && app.IsValidClientSecret(clientSecret)
+}
@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// OAuth2 error codes, RFC 6749 section 5.2
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errUnauthorizedClient   = "unauthorized_client"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errInvalidScope         = "invalid_scope"
+	errServerError          = "server_error"
+
+	// authorization endpoint only, RFC 6749 section 4.1.2.1
+	errUnsupportedResponseType = "unsupported_response_type"
+	errAccessDenied            = "access_denied"
+
+	// OpenID Connect Core 1.0 section 3.1.2.6
+	errConsentRequired = "consent_required"
+)
+
+// writeOAuth2Error write standard {error, error_description} response
+func writeOAuth2Error(res http.ResponseWriter, status int, code, description string) {
+	errorPayload := struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}{
+		Error:            code,
+		ErrorDescription: description,
+	}
+
+	if status == http.StatusUnauthorized {
+		res.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	}
+
+	payload, _ := json.Marshal(errorPayload)
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Pragma", "no-cache")
+	res.WriteHeader(status)
+	res.Write(payload)
+}
+
+// writeJSON write payload as json response that must not be cached
+func writeJSON(res http.ResponseWriter, status int, v interface{}) {
+	payload, _ := json.Marshal(v)
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Pragma", "no-cache")
+	res.WriteHeader(status)
+	res.Write(payload)
+}
+
+// redirectOAuth2Error send authorization error back to client redirect uri, RFC 6749 section 4.1.2.1,
+// redirect uri must already be validated against the registered ones
+func redirectOAuth2Error(res http.ResponseWriter, req *http.Request, redirectURI, state, code, description string) {
+	params := url.Values{}
+	params.Set("error", code)
+	params.Set("error_description", description)
+	if len(state) > 0 {
+		params.Set("state", state)
+	}
+
+	http.Redirect(res, req, redirectURIWithParams(redirectURI, params), http.StatusFound)
+}
+
+// redirectURIWithParams add params to query of redirect uri, keeping query component it was registered with
+func redirectURIWithParams(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
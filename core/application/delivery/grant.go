@@ -0,0 +1,352 @@
+package delivery
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	authCodeModel "github.com/musobarlab/oauth2-go/core/authcode/model"
+	authCodeRepo "github.com/musobarlab/oauth2-go/core/authcode/repository"
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
+	userModel "github.com/musobarlab/oauth2-go/core/user/model"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// grantFunc handle token request of a single grant type, app is the authenticated client
+type grantFunc func(res http.ResponseWriter, app *appModel.Application, oauth2Payload *appModel.OAuth2)
+
+// grants return token endpoint grant handlers by grant_type
+func (h *Handler) grants() map[string]grantFunc {
+	return map[string]grantFunc{
+		"authorization_code": h.authorizationCodeGrant,
+		"refresh_token":      h.refreshTokenGrant,
+		"client_credentials": h.clientCredentialsGrant,
+	}
+}
+
+// GrantTypes return grant types supported by token endpoint
+func (h *Handler) GrantTypes() []string {
+	var grantTypes []string
+	for grantType := range h.grants() {
+		grantTypes = append(grantTypes, grantType)
+	}
+
+	sort.Strings(grantTypes)
+	return grantTypes
+}
+
+// decodeTokenRequest read token request parameters from form encoded body,
+// json body is kept for clients written against the earlier api
+func decodeTokenRequest(req *http.Request) (*appModel.OAuth2, error) {
+	var oauth2Payload appModel.OAuth2
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(req.Body).Decode(&oauth2Payload); err != nil {
+			return nil, err
+		}
+
+		return &oauth2Payload, nil
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	oauth2Payload.GrantType = req.PostForm.Get("grant_type")
+	oauth2Payload.Code = req.PostForm.Get("code")
+	oauth2Payload.RedirectURI = req.PostForm.Get("redirect_uri")
+	oauth2Payload.ClientID = req.PostForm.Get("client_id")
+	oauth2Payload.ClientSecret = req.PostForm.Get("client_secret")
+	oauth2Payload.ClientAssertionType = req.PostForm.Get("client_assertion_type")
+	oauth2Payload.ClientAssertion = req.PostForm.Get("client_assertion")
+	oauth2Payload.Scope = req.PostForm.Get("scope")
+	oauth2Payload.RefreshToken = req.PostForm.Get("refresh_token")
+	oauth2Payload.CodeVerifier = req.PostForm.Get("code_verifier")
+
+	return &oauth2Payload, nil
+}
+
+// authorizationCodeGrant exchange authorization code with access token and refresh token
+func (h *Handler) authorizationCodeGrant(res http.ResponseWriter, app *appModel.Application, oauth2Payload *appModel.OAuth2) {
+	if len(oauth2Payload.Code) <= 0 {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "code is required")
+		return
+	}
+
+	// redeemed code is kept as long as the refresh tokens issued from it, so a late replay still revokes them
+	outputCode := h.AuthorizationCodeRepo.Redeem(oauth2Payload.Code, time.Now().Add(h.RefreshTokenAge))
+	if outputCode.Error != nil {
+		// RFC 6749 section 4.1.2, code used more than once, revoke tokens previously issued from it
+		if outputCode.Error == authCodeRepo.ErrCodeRedeemed {
+			authCode := outputCode.Result.(*authCodeModel.AuthorizationCode)
+			h.revokeFamily(authCode.FamilyID)
+		}
+
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "invalid code")
+		return
+	}
+
+	authCode := outputCode.Result.(*authCodeModel.AuthorizationCode)
+	if authCode.IsExpired() {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "code expired")
+		return
+	}
+
+	if authCode.ClientID != app.ClientID {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "code was issued to another client")
+		return
+	}
+
+	// RFC 6749 section 4.1.3, redirect_uri must be identical when it was included in authorization request
+	if len(authCode.RedirectURI) > 0 && oauth2Payload.RedirectURI != authCode.RedirectURI {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "redirect uri is not equal to the authorization request redirect uri")
+		return
+	}
+
+	codeChallenge := authCode.CodeChallenge
+	if len(codeChallenge) > 0 && !appSecurity.VerifyCodeChallenge(oauth2Payload.CodeVerifier, codeChallenge, authCode.CodeChallengeMethod) {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "invalid code verifier")
+		return
+	}
+
+	if len(codeChallenge) <= 0 && app.IsPublic() {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "code verifier is required for public client")
+		return
+	}
+
+	output := h.UserRepo.FindByID(authCode.UserID)
+	if output.Error != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "user not found")
+		return
+	}
+
+	userRes := output.Result.(*userModel.User)
+
+	h.issueToken(res, userRes, app, tokenGrant{
+		familyID: authCode.FamilyID,
+		scopes:   authCode.Scopes,
+		nonce:    authCode.Nonce,
+		authTime: authCode.AuthTime,
+	})
+}
+
+// refreshTokenGrant rotate refresh token and issue new access token
+// replaying a refresh token that has already been rotated revokes the whole token family
+func (h *Handler) refreshTokenGrant(res http.ResponseWriter, app *appModel.Application, oauth2Payload *appModel.OAuth2) {
+	if len(oauth2Payload.RefreshToken) <= 0 {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "refresh token is required")
+		return
+	}
+
+	output := h.RefreshTokenRepo.FindByToken(oauth2Payload.RefreshToken)
+	if output.Error != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	refreshToken := output.Result.(*tokenModel.RefreshToken)
+	if refreshToken.ClientID != app.ClientID || refreshToken.Revoked || refreshToken.IsExpired() {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	// RFC 6749 section 6, access token may be narrowed to some of the scopes originally granted
+	accessScopes := oauth2Payload.RequestedScopes()
+	for _, scope := range accessScopes {
+		if !scopeModel.HasScope(refreshToken.Scopes, scope) {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidScope, "scope exceeds the scope originally granted")
+			return
+		}
+	}
+
+	output = h.RefreshTokenRepo.Rotate(refreshToken.Token)
+	if output.Error != nil {
+		if output.Error == tokenRepo.ErrRefreshTokenReused {
+			h.revokeFamily(refreshToken.FamilyID)
+		}
+
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	outputUser := h.UserRepo.FindByID(refreshToken.UserID)
+	if outputUser.Error != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidGrant, "user not found")
+		return
+	}
+
+	userRes := outputUser.Result.(*userModel.User)
+
+	h.issueToken(res, userRes, app, tokenGrant{
+		familyID:     refreshToken.FamilyID,
+		scopes:       refreshToken.Scopes,
+		accessScopes: accessScopes,
+		authTime:     refreshToken.AuthTime,
+	})
+}
+
+// tokenGrant data structure
+// authorization granted by the user, carried from authorization code to every refresh token of the family,
+// accessScopes narrow the issued access token when it is not empty, refresh token keeps scopes
+type tokenGrant struct {
+	familyID     string
+	scopes       []string
+	accessScopes []string
+	nonce        string
+	authTime     time.Time
+}
+
+// issueToken generate access token, a new refresh token within the grant family
+// and id_token when openid scope is granted
+func (h *Handler) issueToken(res http.ResponseWriter, userRes *userModel.User, app *appModel.Application, grant tokenGrant) {
+	accessScopes := grant.accessScopes
+	if len(accessScopes) <= 0 {
+		accessScopes = grant.scopes
+	}
+
+	claim := jwtGen.Claim{
+		Issuer:   h.Issuer,
+		Audience: app.ClientID,
+		Subject:  userRes.ID,
+		Email:    userRes.Email,
+		ClientID: app.ClientID,
+		Scopes:   accessScopes,
+	}
+
+	tokenResult := <-h.AccessTokenGenerator.GenerateAccessToken(claim)
+	if tokenResult.Error != nil {
+		writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error generate access token")
+		return
+	}
+
+	accessToken := tokenResult.AccessToken
+
+	token, err := appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error generate refresh token")
+		return
+	}
+
+	now := time.Now()
+	output := h.RefreshTokenRepo.Save(&tokenModel.RefreshToken{
+		Token:                token,
+		FamilyID:             grant.familyID,
+		ClientID:             app.ClientID,
+		UserID:               userRes.ID,
+		Scopes:               grant.scopes,
+		AuthTime:             grant.authTime,
+		AccessTokenID:        accessToken.ID,
+		AccessTokenExpiredAt: accessToken.ExpiredAt,
+		ExpiredAt:            now.Add(h.RefreshTokenAge),
+		CreatedAt:            now,
+	})
+
+	if output.Error != nil {
+		writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error save refresh token")
+		return
+	}
+
+	var idToken string
+	if scopeModel.HasScope(accessScopes, "openid") {
+		idTokenResult := <-h.IDTokenGenerator.GenerateIDToken(jwtGen.IDTokenClaim{
+			Issuer:    h.Issuer,
+			Subject:   userRes.ID,
+			Audience:  app.ClientID,
+			Nonce:     grant.nonce,
+			AuthTime:  grant.authTime,
+			Algorithm: app.IDTokenSignedResponseAlg,
+			Claims:    userRes.Claims(scopeRepo.Claims(h.ScopeRepo, accessScopes)),
+		})
+
+		if idTokenResult.Error != nil {
+			writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error generate id token")
+			return
+		}
+
+		idToken = idTokenResult.IDToken
+	}
+
+	writeTokenResponse(res, accessToken, token, idToken, accessScopes)
+}
+
+// clientCredentialsGrant issue access token for the client itself, no user involved
+func (h *Handler) clientCredentialsGrant(res http.ResponseWriter, app *appModel.Application, oauth2Payload *appModel.OAuth2) {
+	if app.IsPublic() {
+		writeOAuth2Error(res, http.StatusBadRequest, errUnauthorizedClient, "public client can not use client credentials")
+		return
+	}
+
+	scopes, err := h.grantedScopes(oauth2Payload.RequestedScopes(), func(scope string) bool {
+		return h.isClientScope(app, scope)
+	})
+	if err != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidScope, "invalid scope")
+		return
+	}
+
+	claim := jwtGen.Claim{
+		Issuer:   h.Issuer,
+		Audience: app.ClientID,
+		Subject:  app.ClientID,
+		ClientID: app.ClientID,
+		Scopes:   scopes,
+	}
+
+	tokenResult := <-h.AccessTokenGenerator.GenerateAccessToken(claim)
+	if tokenResult.Error != nil {
+		writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error generate access token")
+		return
+	}
+
+	writeTokenResponse(res, tokenResult.AccessToken, "", "", scopes)
+}
+
+// writeTokenResponse write issued tokens to client, RFC 6749 section 5.1
+func writeTokenResponse(res http.ResponseWriter, accessToken jwtGen.AccessToken, refreshToken, idToken string, scopes []string) {
+	writeJSON(res, http.StatusOK, &tokenModel.TokenResponse{
+		AccessToken:  accessToken.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(accessToken.ExpiredAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+		Scope:        strings.Join(scopes, " "),
+	})
+}
+
+// revokeFamily revoke every refresh token of the family and the access tokens issued with them
+func (h *Handler) revokeFamily(familyID string) {
+	output := h.RefreshTokenRepo.RevokeFamily(familyID)
+	if output.Error != nil {
+		return
+	}
+
+	for _, refreshToken := range output.Result.([]*tokenModel.RefreshToken) {
+		if len(refreshToken.AccessTokenID) > 0 {
+			h.Denylist.Revoke(refreshToken.AccessTokenID, refreshToken.AccessTokenExpiredAt)
+		}
+	}
+}
+
+// revokeClient revoke every refresh token of a deleted client and the access tokens issued with them,
+// client credentials access tokens are not tracked, introspection reports them inactive once the client is gone
+func (h *Handler) revokeClient(clientID string) {
+	output := h.RefreshTokenRepo.RevokeClient(clientID)
+	if output.Error != nil {
+		return
+	}
+
+	for _, refreshToken := range output.Result.([]*tokenModel.RefreshToken) {
+		if len(refreshToken.AccessTokenID) > 0 {
+			h.Denylist.Revoke(refreshToken.AccessTokenID, refreshToken.AccessTokenExpiredAt)
+		}
+	}
+}
@@ -1,11 +1,10 @@
 package delivery
 
 import (
-	"encoding/json"
-	"fmt"
 	"html/template"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,25 +12,58 @@ import (
 
 	appModel "github.com/musobarlab/oauth2-go/core/application/model"
 	appRepo "github.com/musobarlab/oauth2-go/core/application/repository"
+	authCodeModel "github.com/musobarlab/oauth2-go/core/authcode/model"
+	authCodeRepo "github.com/musobarlab/oauth2-go/core/authcode/repository"
+	consentModel "github.com/musobarlab/oauth2-go/core/consent/model"
+	consentRepo "github.com/musobarlab/oauth2-go/core/consent/repository"
+	"github.com/musobarlab/oauth2-go/core/key"
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
 
 	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
 	userModel "github.com/musobarlab/oauth2-go/core/user/model"
 	userRepo "github.com/musobarlab/oauth2-go/core/user/repository"
 
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
 	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
 )
 
 // Handler model
 type Handler struct {
-	AppRepo              appRepo.Repository
-	UserRepo             userRepo.Repository
-	Security             appSecurity.Interface
-	AccessTokenGenerator jwtGen.AccessTokenGenerator
+	AppRepo               appRepo.Repository
+	UserRepo              userRepo.Repository
+	AccessTokenGenerator  jwtGen.AccessTokenGenerator
+	IDTokenGenerator      jwtGen.IDTokenGenerator
+	RefreshTokenRepo      tokenRepo.Repository
+	RefreshTokenAge       time.Duration
+	Denylist              tokenRepo.Denylist
+	AuthorizationCodeRepo authCodeRepo.Repository
+	AuthorizationCodeAge  time.Duration
+	Keys                  key.PublicKeyProvider
+	SigningAlgorithms     []string
+	Issuer                string
+	TokenEndpoint         string
+	ClientAssertionReplay tokenRepo.Denylist
+	RegistrationEndpoint  string
+	InitialAccessToken    string
+	ConsentRepo           consentRepo.Repository
+	ConsentRequestRepo    consentRepo.RequestRepository
+	ConsentRequestAge     time.Duration
+	ScopeRepo             scopeRepo.Repository
+	CookieCipher          appSecurity.Interface
 }
 
 // GetAuthorizeUser http handler
 // this handler will used by client to authorize their app
-// http://localhost:9000/get_authorize_user?response_type=code&client_id=58a1a940-5432-4046-8e54-18059f070ebd&redirect_uri=localhost:8000/callback
+// http://localhost:9000/get_authorize_user?response_type=code&client_id=58a1a940-5432-4046-8e54-18059f070ebd&redirect_uri=http://localhost:8000/callback&state=af0ifjsldkj
+// redirect_uri may be omitted when the application has exactly one registered
+// OpenID Connect client add &scope=openid%20profile%20email&nonce=n-0S6_WzA2Mj
+// public client should send PKCE code challenge
+// &code_challenge=E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM&code_challenge_method=S256
+// errors are rendered here only until client and redirect uri are validated,
+// after that they are sent back to the client redirect uri together with state
+// user is asked for consent unless scopes were granted to the client before,
+// &prompt=consent always asks, &prompt=none fails with consent_required instead of asking
 func (h *Handler) GetAuthorizeUser() http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		var tmpl *template.Template
@@ -43,7 +75,7 @@ func (h *Handler) GetAuthorizeUser() http.HandlerFunc {
 			Done: false,
 		}
 
-		c, err := req.Cookie("user_id")
+		session, err := userModel.SessionFromRequest(h.CookieCipher, req)
 		if err != nil {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
 			message.Message = "you should login first"
@@ -52,9 +84,7 @@ func (h *Handler) GetAuthorizeUser() http.HandlerFunc {
 			return
 		}
 
-		userID := c.Value
-
-		output := h.UserRepo.FindByID(userID)
+		output := h.UserRepo.FindByID(session.UserID)
 		if output.Error != nil {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
 			message.Message = "invalid session"
@@ -65,174 +95,342 @@ func (h *Handler) GetAuthorizeUser() http.HandlerFunc {
 
 		userRes := output.Result.(*userModel.User)
 
-		responseType, ok := req.URL.Query()["response_type"]
+		clientIDs, ok := req.URL.Query()["client_id"]
 		if !ok {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = "response type is required"
+			message.Message = "client id is required"
 
 			tmpl.Execute(res, message)
 			return
 		}
 
-		if responseType[0] != "code" {
+		outputApp := h.AppRepo.FindByID(clientIDs[0])
+		if outputApp.Error != nil {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = "invalid response type is required"
+			message.Message = outputApp.Error.Error()
 
 			tmpl.Execute(res, message)
 			return
 		}
 
-		clientIDs, ok := req.URL.Query()["client_id"]
-		if !ok {
+		app := outputApp.Result.(*appModel.Application)
+
+		// redirect_uri may be omitted when the application registered exactly one
+		requestedRedirectURI := req.URL.Query().Get("redirect_uri")
+		redirectURI, err := app.MatchRedirectURI(requestedRedirectURI)
+		if err != nil {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = "client id is required"
+			message.Message = err.Error()
 
 			tmpl.Execute(res, message)
 			return
 		}
 
-		redirectURIs, ok := req.URL.Query()["redirect_uri"]
-		if !ok {
-			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = "redirect uri is required"
+		// redirect uri is trusted from here, errors go back to the client
+		state := req.URL.Query().Get("state")
 
-			tmpl.Execute(res, message)
+		responseType := req.URL.Query().Get("response_type")
+		if len(responseType) <= 0 {
+			redirectOAuth2Error(res, req, redirectURI, state, errInvalidRequest, "response type is required")
 			return
 		}
 
-		// state, ok := req.URL.Query()["state"]
-		// if !ok {
-		// 	tmpl = template.Must(template.ParseFiles("./static/error.html"))
-		// 	message.Message = "state is required"
+		if responseType != "code" {
+			redirectOAuth2Error(res, req, redirectURI, state, errUnsupportedResponseType, "response type is not supported")
+			return
+		}
 
-		// 	tmpl.Execute(res, message)
-		// 	return
-		// }
+		if !app.IsAllowedResponseType(responseType) {
+			redirectOAuth2Error(res, req, redirectURI, state, errUnauthorizedClient, "response type is not allowed for this client")
+			return
+		}
 
-		outputApp := h.AppRepo.FindByID(clientIDs[0])
-		if outputApp.Error != nil {
-			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = outputApp.Error.Error()
+		codeChallenge := req.URL.Query().Get("code_challenge")
+		codeChallengeMethod := req.URL.Query().Get("code_challenge_method")
 
-			tmpl.Execute(res, message)
+		if len(codeChallenge) <= 0 && app.IsPublic() {
+			redirectOAuth2Error(res, req, redirectURI, state, errInvalidRequest, "code challenge is required for public client")
 			return
 		}
 
-		app := outputApp.Result.(*appModel.Application)
+		if len(codeChallenge) > 0 {
+			if len(codeChallengeMethod) <= 0 {
+				codeChallengeMethod = appSecurity.CodeChallengeMethodPlain
+			}
 
-		if app.RedirectURI != redirectURIs[0] {
-			tmpl = template.Must(template.ParseFiles("./static/error.html"))
-			message.Message = "redirect uri is not equal to your redirect uri app"
+			if !appSecurity.IsValidCodeChallengeMethod(codeChallengeMethod) {
+				redirectOAuth2Error(res, req, redirectURI, state, errInvalidRequest, "code challenge method is not supported")
+				return
+			}
 
-			tmpl.Execute(res, message)
+			if len(codeChallenge) < 43 || len(codeChallenge) > 128 {
+				redirectOAuth2Error(res, req, redirectURI, state, errInvalidRequest, "invalid code challenge")
+				return
+			}
+		}
+
+		scopes, err := h.grantedScopes(strings.Fields(req.URL.Query().Get("scope")), func(scope string) bool {
+			return h.isSupportedScope(app, scope)
+		})
+		if err != nil {
+			redirectOAuth2Error(res, req, redirectURI, state, errInvalidScope, "invalid scope")
 			return
 		}
 
-		code := fmt.Sprintf("%s|%s|%s", userRes.ID, app.ClientID, app.RedirectURI)
+		prompts := strings.Fields(req.URL.Query().Get("prompt"))
+		if contains(prompts, "none") && len(prompts) > 1 {
+			redirectOAuth2Error(res, req, redirectURI, state, errInvalidRequest, "prompt none can not be combined with other values")
+			return
+		}
 
-		encryptedCode, err := h.Security.Encrypt(code)
+		consentRequest := &consentModel.Request{
+			UserID:               userRes.ID,
+			ClientID:             app.ClientID,
+			RedirectURI:          redirectURI,
+			RequestedRedirectURI: requestedRedirectURI,
+			State:                state,
+			Scopes:               scopes,
+			Nonce:                req.URL.Query().Get("nonce"),
+			CodeChallenge:        codeChallenge,
+			CodeChallengeMethod:  codeChallengeMethod,
+			AuthTime:             session.AuthTime,
+		}
 
-		http.Redirect(res, req, fmt.Sprintf("%s?code=%s", app.RedirectURI, encryptedCode), 302)
+		if !contains(prompts, "consent") && h.hasConsent(userRes.ID, app.ClientID, scopes) {
+			h.issueAuthorizationCode(res, req, consentRequest)
+			return
+		}
 
+		if contains(prompts, "none") {
+			redirectOAuth2Error(res, req, redirectURI, state, errConsentRequired, "user consent is required")
+			return
+		}
+
+		challenge, err := appSecurity.GenerateRandomToken(32)
+		if err != nil {
+			redirectOAuth2Error(res, req, redirectURI, state, errServerError, "error generate consent challenge")
+			return
+		}
+
+		consentRequest.Challenge = challenge
+		consentRequest.ExpiredAt = time.Now().Add(h.ConsentRequestAge)
+
+		outputRequest := h.ConsentRequestRepo.Save(consentRequest)
+		if outputRequest.Error != nil {
+			redirectOAuth2Error(res, req, redirectURI, state, errServerError, "error save consent request")
+			return
+		}
+
+		consentMessage := struct {
+			Name      string
+			ClientID  string
+			LogoURI   string
+			Scopes    []*scopeModel.Scope
+			Challenge string
+		}{
+			Name:      app.Name,
+			ClientID:  app.ClientID,
+			LogoURI:   app.LogoURI,
+			Challenge: challenge,
+		}
+
+		if outputScopes := h.ScopeRepo.FindByNames(scopes); outputScopes.Error == nil {
+			consentMessage.Scopes = outputScopes.Result.([]*scopeModel.Scope)
+		}
+
+		// consent page must not be cached nor framed by another site
+		res.Header().Set("Cache-Control", "no-store")
+		res.Header().Set("X-Frame-Options", "DENY")
+
+		tmpl = template.Must(template.ParseFiles("./static/consent.html"))
+		tmpl.Execute(res, consentMessage)
 	}
 }
 
-// OAuth2Handler http handler
-// localhost:9000/api/oauth2/token
-// payload:
-// {
-// 	"grant_type": "authorization_code",
-// 	"code": "wI2kNEvM0EcAZEtKKE2k4Ki3rL6drFWVI_YxmniYwqgjSSA2eQ78UnW6LbwaxubS-L4JXQcVTsORsSQf8IPijmuRFoxLM0c3_2TmzD_m9GK9pdSaQDpVczuOCJECBuNV52m4TDudn-s0kpvlASwTKwVp2bGLMu6d",
-// 	"redirect_uri": "http://localhost:8000/callback",
-// 	"client_id": "c4c96bb4-8979-42b3-a09d-e52b7584345e",
-// 	"client_secret": "TfPeCSvWPU"
-// }
-func (h *Handler) OAuth2Handler() http.HandlerFunc {
+// PostAuthorizeUser http handler
+// consent page answer, decision=allow remember scopes granted to the client and issue authorization code,
+// any other decision send access_denied to the client
+// payload (application/x-www-form-urlencoded): challenge=3q8CKvGd2wY1Ut0Ukq0bRzq2o6xJf7iP3z9QJ3xF6sM&decision=allow
+func (h *Handler) PostAuthorizeUser() http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
+		var tmpl *template.Template
+
+		message := struct {
+			Done    bool
+			Message string
+		}{
+			Message: "invalid method",
+		}
+
 		if req.Method != http.MethodPost {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "invalid method"}`))
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			tmpl.Execute(res, message)
 			return
 		}
 
-		var oauth2Payload appModel.OAuth2
-		if err := json.NewDecoder(req.Body).Decode(&oauth2Payload); err != nil {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "invalid payload"}`))
+		session, err := userModel.SessionFromRequest(h.CookieCipher, req)
+		if err != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "you should login first"
+
+			tmpl.Execute(res, message)
 			return
 		}
 
-		if oauth2Payload.GrantType != "authorization_code" {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "invalid grant_type"}`))
+		output := h.ConsentRequestRepo.Take(req.FormValue("challenge"))
+		if output.Error != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = output.Error.Error()
+
+			tmpl.Execute(res, message)
 			return
 		}
 
-		code, err := h.Security.Decrypt(oauth2Payload.Code)
-		if err != nil {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "invalid code"}`))
+		consentRequest := output.Result.(*consentModel.Request)
+
+		// challenge belongs to the user it was shown to
+		if consentRequest.UserID != session.UserID {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "invalid session"
+
+			tmpl.Execute(res, message)
 			return
 		}
 
-		codes := strings.Split(code, "|")
-		userID := codes[0]
-		clientID := codes[1]
-		redirectURI := codes[2]
-		fmt.Println(codes)
-
-		output := h.UserRepo.FindByID(userID)
-		if output.Error != nil {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "user not found"}`))
+		if req.FormValue("decision") != "allow" {
+			redirectOAuth2Error(res, req, consentRequest.RedirectURI, consentRequest.State, errAccessDenied, "user denied the request")
 			return
 		}
 
-		userRes := output.Result.(*userModel.User)
+		outputConsent := h.ConsentRepo.Find(consentRequest.UserID, consentRequest.ClientID)
 
-		outputApp := h.AppRepo.FindByID(clientID)
-		if outputApp.Error != nil {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "client app found"}`))
+		consent := &consentModel.Consent{
+			UserID:    consentRequest.UserID,
+			ClientID:  consentRequest.ClientID,
+			CreatedAt: time.Now(),
+		}
+
+		// stored consent may be read concurrently, a copy is changed and saved in its place
+		if outputConsent.Error == nil {
+			stored := *outputConsent.Result.(*consentModel.Consent)
+			stored.Scopes = append([]string(nil), stored.Scopes...)
+			consent = &stored
+		}
+
+		consent.Grant(consentRequest.Scopes)
+
+		outputConsent = h.ConsentRepo.Save(consent)
+		if outputConsent.Error != nil {
+			redirectOAuth2Error(res, req, consentRequest.RedirectURI, consentRequest.State, errServerError, "error save consent")
 			return
 		}
 
-		app := outputApp.Result.(*appModel.Application)
+		h.issueAuthorizationCode(res, req, consentRequest)
+	}
+}
+
+// hasConsent return true when user has already granted every scope to the client
+func (h *Handler) hasConsent(userID, clientID string, scopes []string) bool {
+	output := h.ConsentRepo.Find(userID, clientID)
+	if output.Error != nil {
+		return false
+	}
+
+	return output.Result.(*consentModel.Consent).Covers(scopes)
+}
+
+// issueAuthorizationCode save authorization code of the approved request and redirect user back to the client
+func (h *Handler) issueAuthorizationCode(res http.ResponseWriter, req *http.Request, consentRequest *consentModel.Request) {
+	code, err := appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		redirectOAuth2Error(res, req, consentRequest.RedirectURI, consentRequest.State, errServerError, "error generate authorization code")
+		return
+	}
+
+	now := time.Now()
+	outputCode := h.AuthorizationCodeRepo.Save(&authCodeModel.AuthorizationCode{
+		Code:                code,
+		ClientID:            consentRequest.ClientID,
+		UserID:              consentRequest.UserID,
+		RedirectURI:         consentRequest.RequestedRedirectURI,
+		CodeChallenge:       consentRequest.CodeChallenge,
+		CodeChallengeMethod: consentRequest.CodeChallengeMethod,
+		Scopes:              consentRequest.Scopes,
+		Nonce:               consentRequest.Nonce,
+		AuthTime:            consentRequest.AuthTime,
+		FamilyID:            uuid.NewV4().String(),
+		ExpiredAt:           now.Add(h.AuthorizationCodeAge),
+		CreatedAt:           now,
+	})
+
+	if outputCode.Error != nil {
+		redirectOAuth2Error(res, req, consentRequest.RedirectURI, consentRequest.State, errServerError, "error save authorization code")
+		return
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if len(consentRequest.State) > 0 {
+		params.Set("state", consentRequest.State)
+	}
+
+	http.Redirect(res, req, redirectURIWithParams(consentRequest.RedirectURI, params), http.StatusFound)
+}
+
+// OAuth2Handler http handler
+// RFC 6749 token endpoint
+// localhost:9000/api/oauth2/token
+// payload (application/x-www-form-urlencoded), client authenticates with HTTP Basic, client_id and client_secret
+// or client_assertion_type=urn%3Aietf%3Aparams%3Aoauth%3Aclient-assertion-type%3Ajwt-bearer&client_assertion=eyJhbGciOiJSUzI1NiJ9...:
+// grant_type=authorization_code&code=SplxlOBeZQQYbYS6WxSbIA3m0JVk6XRn8oPq1wL2c9E
+// &redirect_uri=http%3A%2F%2Flocalhost%3A8000%2Fcallback&code_verifier=dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk
+// public client sends client_id and code_verifier without client_secret
+// or
+// grant_type=refresh_token&refresh_token=3q8CKvGd2wY1Ut0Ukq0bRzq2o6xJf7iP3z9QJ3xF6sM
+// or
+// grant_type=client_credentials&scope=report.read
+// the same parameters are still accepted as application/json body
+func (h *Handler) OAuth2Handler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeOAuth2Error(res, http.StatusMethodNotAllowed, errInvalidRequest, "invalid method")
+			return
+		}
 
-		if app.RedirectURI != redirectURI {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(400)
-			res.Write([]byte(`{"success": false, "code": 400, "message": "redirect uri is not equal to your redirect uri app"}`))
+		oauth2Payload, err := decodeTokenRequest(req)
+		if err != nil {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "invalid payload")
 			return
 		}
 
-		claim := jwtGen.Claim{
-			Issuer:   "wuriyanto.com",
-			Audience: userRes.ID,
-			Subject:  userRes.ID,
-			Email:    userRes.Email,
+		if len(oauth2Payload.GrantType) <= 0 {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "grant_type is required")
+			return
 		}
 
-		tokenResult := <-h.AccessTokenGenerator.GenerateAccessToken(claim)
-		if tokenResult.Error != nil {
-			res.Header().Add("Content-Type", "application/json")
-			res.WriteHeader(401)
-			res.Write([]byte(`{"success": false, "code": 401, "message": "invalid username or password"}`))
+		grant, ok := h.grants()[oauth2Payload.GrantType]
+		if !ok {
+			writeOAuth2Error(res, http.StatusBadRequest, errUnsupportedGrantType, "invalid grant_type")
 			return
 		}
 
-		accessToken := tokenResult.AccessToken
+		app, err := h.authenticateClientWith(req, clientCredentials{
+			clientID:            oauth2Payload.ClientID,
+			clientSecret:        oauth2Payload.ClientSecret,
+			clientAssertionType: oauth2Payload.ClientAssertionType,
+			clientAssertion:     oauth2Payload.ClientAssertion,
+		}, true)
+		if err != nil {
+			writeOAuth2Error(res, http.StatusUnauthorized, errInvalidClient, err.Error())
+			return
+		}
 
-		res.Header().Add("Content-Type", "application/json")
-		res.WriteHeader(200)
-		res.Write([]byte(fmt.Sprintf(`{"success": false, "code": 200, "message": "exchange access token", "data": "%s"}`, fmt.Sprintf("Bearer %s", accessToken.AccessToken))))
+		if !app.IsAllowedGrantType(oauth2Payload.GrantType) {
+			writeOAuth2Error(res, http.StatusBadRequest, errUnauthorizedClient, "grant type is not allowed for this client")
+			return
+		}
 
+		grant(res, app, oauth2Payload)
 	}
 }
 
@@ -259,9 +457,13 @@ func (h *Handler) GetRegisterHandler() http.HandlerFunc {
 		var tmpl *template.Template
 
 		message := struct {
-			Done bool
+			Done              bool
+			SigningAlgorithms []string
+			Scopes            []string
 		}{
-			Done: false,
+			Done:              false,
+			SigningAlgorithms: h.SigningAlgorithms,
+			Scopes:            h.Scopes(),
 		}
 
 		tmpl = template.Must(template.ParseFiles("./static/new_app.html"))
@@ -281,7 +483,11 @@ func (h *Handler) PostRegisterHandler() http.HandlerFunc {
 			Name         string
 			ClientID     string
 			ClientSecret string
-			RedirectURI  string
+			RedirectURIs []string
+			Public       bool
+			Scopes       []string
+			IDTokenAlg   string
+			AuthMethod   string
 		}{
 			Message: "invalid method",
 		}
@@ -293,7 +499,7 @@ func (h *Handler) PostRegisterHandler() http.HandlerFunc {
 		}
 
 		appName := req.FormValue("app_name")
-		redirectURI := req.FormValue("redirect_uri")
+		redirectURIs := strings.Fields(req.FormValue("redirect_uri"))
 
 		if len(appName) <= 0 {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
@@ -303,7 +509,7 @@ func (h *Handler) PostRegisterHandler() http.HandlerFunc {
 			return
 		}
 
-		if len(redirectURI) <= 0 {
+		if len(redirectURIs) <= 0 {
 			tmpl = template.Must(template.ParseFiles("./static/error.html"))
 			message.Message = "redirect URI is required"
 
@@ -311,14 +517,84 @@ func (h *Handler) PostRegisterHandler() http.HandlerFunc {
 			return
 		}
 
+		for _, redirectURI := range redirectURIs {
+			if err := appModel.ValidateRedirectURI(redirectURI); err != nil {
+				tmpl = template.Must(template.ParseFiles("./static/error.html"))
+				message.Message = err.Error()
+
+				tmpl.Execute(res, message)
+				return
+			}
+		}
+
+		public := req.FormValue("public_client") == "true"
+		scopes := strings.Fields(req.FormValue("scopes"))
+		for _, scope := range scopes {
+			if !h.isRegisteredScope(scope) {
+				tmpl = template.Must(template.ParseFiles("./static/error.html"))
+				message.Message = "scope " + scope + " is not registered"
+
+				tmpl.Execute(res, message)
+				return
+			}
+		}
+
+		idTokenAlg := req.FormValue("id_token_signed_response_alg")
+		if len(idTokenAlg) > 0 && !h.isSupportedSigningAlgorithm(idTokenAlg) {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "id token signing algorithm is not supported"
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		authMethod := req.FormValue("token_endpoint_auth_method")
+		if len(authMethod) <= 0 {
+			authMethod = appModel.AuthMethodClientSecretBasic
+		}
+
+		if public {
+			authMethod = appModel.AuthMethodNone
+		}
+
+		jwks, publicKey, err := registeredKeys(authMethod, req.FormValue("jwks"), req.FormValue("public_key"))
+		if err != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = err.Error()
+
+			tmpl.Execute(res, message)
+			return
+		}
+
 		clientID := uuid.NewV4().String()
 		clientSecret := generateSecret(10)
+		switch authMethod {
+		case appModel.AuthMethodNone, appModel.AuthMethodPrivateKeyJWT:
+			clientSecret = ""
+		case appModel.AuthMethodClientSecretJWT:
+			// secret is the HMAC key of client assertion, it must be long enough for HS256
+			clientSecret, err = appSecurity.GenerateRandomToken(32)
+			if err != nil {
+				tmpl = template.Must(template.ParseFiles("./static/error.html"))
+				message.Message = "error generate client secret"
+
+				tmpl.Execute(res, message)
+				return
+			}
+		}
 
 		output := h.AppRepo.Save(&appModel.Application{
-			Name:         appName,
-			ClientID:     clientID,
-			ClientSecret: clientSecret,
-			RedirectURI:  redirectURI,
+			Name:                     appName,
+			ClientID:                 clientID,
+			ClientSecret:             clientSecret,
+			RedirectURIs:             redirectURIs,
+			Public:                   public,
+			Scopes:                   scopes,
+			IDTokenSignedResponseAlg: idTokenAlg,
+			TokenEndpointAuthMethod:  authMethod,
+			JWKS:                     jwks,
+			PublicKey:                publicKey,
+			CreatedAt:                time.Now(),
 		})
 
 		if output.Error != nil {
@@ -337,7 +613,11 @@ func (h *Handler) PostRegisterHandler() http.HandlerFunc {
 		message.Name = app.Name
 		message.ClientID = app.ClientID
 		message.ClientSecret = app.ClientSecret
-		message.RedirectURI = app.RedirectURI
+		message.RedirectURIs = app.RedirectURIs
+		message.Public = app.Public
+		message.Scopes = app.Scopes
+		message.IDTokenAlg = app.IDTokenSignedResponseAlg
+		message.AuthMethod = app.TokenEndpointAuthMethod
 		tmpl.Execute(res, message)
 
 	}
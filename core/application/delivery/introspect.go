@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"net/http"
+	"strings"
+
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// introspector return nil when the token is not recognized
+type introspector func(token string) *tokenModel.Introspection
+
+// IntrospectHandler http handler
+// RFC 7662 token introspection, caller must authenticate as confidential client
+// localhost:9000/api/oauth2/introspect
+// payload (application/x-www-form-urlencoded):
+// token=eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9...&token_type_hint=access_token
+func (h *Handler) IntrospectHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeOAuth2Error(res, http.StatusMethodNotAllowed, errInvalidRequest, "invalid method")
+			return
+		}
+
+		if _, err := h.authenticateClient(req, false); err != nil {
+			writeOAuth2Error(res, http.StatusUnauthorized, errInvalidClient, err.Error())
+			return
+		}
+
+		token := req.PostFormValue("token")
+		if len(token) <= 0 {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "token is required")
+			return
+		}
+
+		writeJSON(res, http.StatusOK, h.introspect(token, req.PostFormValue("token_type_hint")))
+	}
+}
+
+// introspect look the token up on every known token type, starting with the hinted one
+func (h *Handler) introspect(token, tokenTypeHint string) *tokenModel.Introspection {
+	introspectors := []introspector{h.introspectAccessToken, h.introspectRefreshToken}
+	if tokenTypeHint == "refresh_token" {
+		introspectors = []introspector{h.introspectRefreshToken, h.introspectAccessToken}
+	}
+
+	for _, i := range introspectors {
+		if introspection := i(token); introspection != nil {
+			return introspection
+		}
+	}
+
+	return &tokenModel.Introspection{Active: false}
+}
+
+// introspectAccessToken introspect jwt access token
+func (h *Handler) introspectAccessToken(token string) *tokenModel.Introspection {
+	claims, err := jwtGen.ParseAccessToken(token, h.Keys)
+	if err != nil {
+		return nil
+	}
+
+	if h.Denylist.IsRevoked(claims.Id) {
+		return &tokenModel.Introspection{Active: false}
+	}
+
+	// token of a deleted client is no longer active
+	if len(claims.ClientID) > 0 && h.AppRepo.FindByID(claims.ClientID).Error != nil {
+		return &tokenModel.Introspection{Active: false}
+	}
+
+	return &tokenModel.Introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		Subject:   claims.Subject,
+		ExpiresAt: claims.ExpiresAt,
+		IssuedAt:  claims.IssuedAt,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+	}
+}
+
+// introspectRefreshToken introspect opaque refresh token
+func (h *Handler) introspectRefreshToken(token string) *tokenModel.Introspection {
+	output := h.RefreshTokenRepo.FindByToken(token)
+	if output.Error != nil {
+		return nil
+	}
+
+	refreshToken := output.Result.(*tokenModel.RefreshToken)
+	if refreshToken.Revoked || refreshToken.Rotated || refreshToken.IsExpired() {
+		return &tokenModel.Introspection{Active: false}
+	}
+
+	return &tokenModel.Introspection{
+		Active:    true,
+		Scope:     strings.Join(refreshToken.Scopes, " "),
+		ClientID:  refreshToken.ClientID,
+		TokenType: "refresh_token",
+		Subject:   refreshToken.UserID,
+		ExpiresAt: refreshToken.ExpiredAt.Unix(),
+		IssuedAt:  refreshToken.CreatedAt.Unix(),
+		Audience:  refreshToken.ClientID,
+		Issuer:    h.Issuer,
+	}
+}
@@ -0,0 +1,137 @@
+package delivery
+
+import (
+	"fmt"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	"github.com/musobarlab/oauth2-go/core/key"
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+)
+
+// isSupportedScope return true when scope is registered and allowed for the application,
+// OpenID Connect scopes are allowed for every client asking user authorization
+func (h *Handler) isSupportedScope(app *appModel.Application, scope string) bool {
+	if !h.isRegisteredScope(scope) {
+		return false
+	}
+
+	return scopeModel.IsOpenIDConnect(scope) || app.IsAllowedScope(scope)
+}
+
+// isClientScope return true when scope is registered and allowed for the application acting on its own behalf,
+// OpenID Connect scopes are about users so client credentials can not have them
+func (h *Handler) isClientScope(app *appModel.Application, scope string) bool {
+	return h.isRegisteredScope(scope) && !scopeModel.IsOpenIDConnect(scope) && app.IsAllowedScope(scope)
+}
+
+// isRegisteredScope function
+func (h *Handler) isRegisteredScope(scope string) bool {
+	return h.ScopeRepo.FindByName(scope).Error == nil
+}
+
+// isSelfRegistrableScope return true when scope may be registered without initial access token,
+// OpenID Connect scopes need user consent and default scopes are granted to every client anyway
+func (h *Handler) isSelfRegistrableScope(scope string) bool {
+	if scopeModel.IsOpenIDConnect(scope) {
+		return true
+	}
+
+	output := h.ScopeRepo.FindByName(scope)
+	return output.Error == nil && output.Result.(*scopeModel.Scope).Default
+}
+
+// grantedScopes check every requested scope is allowed,
+// empty request is granted the default scopes that are allowed, RFC 6749 section 3.3
+func (h *Handler) grantedScopes(requested []string, allowed func(string) bool) ([]string, error) {
+	if len(requested) > 0 {
+		for _, scope := range requested {
+			if !allowed(scope) {
+				return nil, fmt.Errorf("scope %s is not allowed", scope)
+			}
+		}
+
+		return requested, nil
+	}
+
+	output := h.ScopeRepo.FindAll()
+	if output.Error != nil {
+		return nil, output.Error
+	}
+
+	var scopes []string
+	for _, scope := range output.Result.([]*scopeModel.Scope) {
+		if scope.Default && allowed(scope.Name) {
+			scopes = append(scopes, scope.Name)
+		}
+	}
+
+	return scopes, nil
+}
+
+// Scopes return registered scopes
+func (h *Handler) Scopes() []string {
+	output := h.ScopeRepo.FindAll()
+	if output.Error != nil {
+		return nil
+	}
+
+	var scopes []string
+	for _, scope := range output.Result.([]*scopeModel.Scope) {
+		scopes = append(scopes, scope.Name)
+	}
+
+	return scopes
+}
+
+// ResponseTypes return response types supported by authorization endpoint
+func (h *Handler) ResponseTypes() []string {
+	return []string{"code"}
+}
+
+// CodeChallengeMethods return PKCE code challenge methods supported by authorization endpoint
+func (h *Handler) CodeChallengeMethods() []string {
+	return []string{appSecurity.CodeChallengeMethodS256, appSecurity.CodeChallengeMethodPlain}
+}
+
+// TokenEndpointAuthMethods return client authentication methods supported by token endpoint
+func (h *Handler) TokenEndpointAuthMethods() []string {
+	return []string{
+		appModel.AuthMethodClientSecretBasic,
+		appModel.AuthMethodClientSecretPost,
+		appModel.AuthMethodClientSecretJWT,
+		appModel.AuthMethodPrivateKeyJWT,
+		appModel.AuthMethodNone,
+	}
+}
+
+// EndpointAuthMethods return client authentication methods supported by introspection and revocation endpoint
+func (h *Handler) EndpointAuthMethods() []string {
+	return []string{
+		appModel.AuthMethodClientSecretBasic,
+		appModel.AuthMethodClientSecretPost,
+		appModel.AuthMethodClientSecretJWT,
+		appModel.AuthMethodPrivateKeyJWT,
+	}
+}
+
+// EndpointAuthSigningAlgorithms return jws algorithms accepted for private_key_jwt and client_secret_jwt assertion
+func (h *Handler) EndpointAuthSigningAlgorithms() []string {
+	return []string{key.RS256, key.PS256, key.ES256, key.EdDSA, "HS256"}
+}
+
+// Claims return claims this server is able to release
+func (h *Handler) Claims() []string {
+	return []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "email_verified"}
+}
+
+// isSupportedSigningAlgorithm function
+func (h *Handler) isSupportedSigningAlgorithm(alg string) bool {
+	for _, a := range h.SigningAlgorithms {
+		if a == alg {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,311 @@
+package delivery
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	"github.com/musobarlab/oauth2-go/middleware"
+)
+
+// RFC 7591 section 3.2.2 error codes
+const (
+	errInvalidRedirectURI    = "invalid_redirect_uri"
+	errInvalidClientMetadata = "invalid_client_metadata"
+)
+
+// RegisterHandler http handler
+// RFC 7591 dynamic client registration, gated by InitialAccessToken bearer token when it is set,
+// without it clients may register only OpenID Connect and default scopes
+// localhost:9000/register
+// payload (application/json):
+// {"client_name": "Report Dashboard", "redirect_uris": ["https://dashboard.example.com/callback"],
+// "grant_types": ["authorization_code"], "token_endpoint_auth_method": "client_secret_basic", "scope": "openid report.read"}
+func (h *Handler) RegisterHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeOAuth2Error(res, http.StatusMethodNotAllowed, errInvalidRequest, "invalid method")
+			return
+		}
+
+		trusted := false
+		if len(h.InitialAccessToken) > 0 {
+			token, err := middleware.BearerToken(req, false)
+			if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(h.InitialAccessToken)) != 1 {
+				middleware.WriteBearerError(res, http.StatusUnauthorized, "register", middleware.ErrorInvalidToken, "initial access token is required", "")
+				return
+			}
+
+			trusted = true
+		}
+
+		var metadata appModel.ClientMetadata
+		if err := json.NewDecoder(req.Body).Decode(&metadata); err != nil {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidClientMetadata, "invalid payload")
+			return
+		}
+
+		app := &appModel.Application{
+			ClientID:  uuid.NewV4().String(),
+			CreatedAt: time.Now(),
+		}
+
+		if code, err := h.applyClientMetadata(app, &metadata, trusted); err != nil {
+			writeOAuth2Error(res, http.StatusBadRequest, code, err.Error())
+			return
+		}
+
+		registrationAccessToken, err := appSecurity.GenerateRandomToken(32)
+		if err != nil {
+			writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error generate registration access token")
+			return
+		}
+
+		app.SetRegistrationAccessToken(registrationAccessToken)
+
+		output := h.AppRepo.Save(app)
+		if output.Error != nil {
+			writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error save client")
+			return
+		}
+
+		information := h.clientInformation(app)
+		information.RegistrationAccessToken = registrationAccessToken
+		writeJSON(res, http.StatusCreated, information)
+	}
+}
+
+// ClientConfigurationHandler http handler
+// RFC 7592 client configuration endpoint, client authenticates with registration_access_token
+// GET localhost:9000/register/{client_id} read, PUT replace metadata, DELETE deregister
+func (h *Handler) ClientConfigurationHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		clientID := strings.TrimPrefix(req.URL.Path, "/register/")
+
+		// unknown client and invalid token get the same answer, so client ids can not be probed
+		token, err := middleware.BearerToken(req, false)
+		if err != nil {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, "register", middleware.ErrorInvalidToken, "registration access token is required", "")
+			return
+		}
+
+		outputApp := h.AppRepo.FindByID(clientID)
+		if outputApp.Error != nil {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, "register", middleware.ErrorInvalidToken, "invalid registration access token", "")
+			return
+		}
+
+		app := outputApp.Result.(*appModel.Application)
+		if !app.IsValidRegistrationAccessToken(token) {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, "register", middleware.ErrorInvalidToken, "invalid registration access token", "")
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			writeJSON(res, http.StatusOK, h.clientInformation(app))
+		case http.MethodPut:
+			h.updateClient(res, req, app)
+		case http.MethodDelete:
+			h.AppRepo.Delete(app.ClientID)
+			h.revokeClient(app.ClientID)
+			res.Header().Set("Cache-Control", "no-store")
+			res.WriteHeader(http.StatusNoContent)
+		default:
+			writeOAuth2Error(res, http.StatusMethodNotAllowed, errInvalidRequest, "invalid method")
+		}
+	}
+}
+
+// updateClient replace client metadata, RFC 7592 section 2.2
+func (h *Handler) updateClient(res http.ResponseWriter, req *http.Request, app *appModel.Application) {
+	var update appModel.ClientUpdate
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidClientMetadata, "invalid payload")
+		return
+	}
+
+	if update.ClientID != app.ClientID {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidClientMetadata, "client_id does not match")
+		return
+	}
+
+	if len(update.ClientSecret) > 0 && !app.IsValidClientSecret(update.ClientSecret) {
+		writeOAuth2Error(res, http.StatusBadRequest, errInvalidClientMetadata, "client_secret does not match")
+		return
+	}
+
+	updated := *app
+	if code, err := h.applyClientMetadata(&updated, &update.ClientMetadata, false); err != nil {
+		writeOAuth2Error(res, http.StatusBadRequest, code, err.Error())
+		return
+	}
+
+	output := h.AppRepo.Save(&updated)
+	if output.Error != nil {
+		writeOAuth2Error(res, http.StatusInternalServerError, errServerError, "error save client")
+		return
+	}
+
+	writeJSON(res, http.StatusOK, h.clientInformation(&updated))
+}
+
+// applyClientMetadata validate metadata and copy it to app, return error code and description when invalid.
+// Client secret is issued when the client becomes confidential and dropped when it no longer needs one,
+// client that is not trusted may add only self registrable scopes to the scopes it already has
+func (h *Handler) applyClientMetadata(app *appModel.Application, metadata *appModel.ClientMetadata, trusted bool) (string, error) {
+	grantTypes := metadata.GrantTypes
+	if len(grantTypes) <= 0 {
+		grantTypes = []string{"authorization_code"}
+	}
+
+	for _, grantType := range grantTypes {
+		if !contains(h.GrantTypes(), grantType) {
+			return errInvalidClientMetadata, errors.New("grant type is not supported")
+		}
+	}
+
+	responseTypes := metadata.ResponseTypes
+	if len(responseTypes) <= 0 && contains(grantTypes, "authorization_code") {
+		responseTypes = []string{"code"}
+	}
+
+	for _, responseType := range responseTypes {
+		if !contains(h.ResponseTypes(), responseType) {
+			return errInvalidClientMetadata, errors.New("response type is not supported")
+		}
+	}
+
+	if contains(grantTypes, "authorization_code") != contains(responseTypes, "code") {
+		return errInvalidClientMetadata, errors.New("code response type and authorization_code grant type must be registered together")
+	}
+
+	if contains(grantTypes, "authorization_code") && len(metadata.RedirectURIs) <= 0 {
+		return errInvalidRedirectURI, errors.New("redirect uri is required for authorization_code grant type")
+	}
+
+	for _, redirectURI := range metadata.RedirectURIs {
+		if err := appModel.ValidateRedirectURI(redirectURI); err != nil {
+			return errInvalidRedirectURI, err
+		}
+	}
+
+	authMethod := metadata.TokenEndpointAuthMethod
+	if len(authMethod) <= 0 {
+		authMethod = appModel.AuthMethodClientSecretBasic
+	}
+
+	if !contains(h.TokenEndpointAuthMethods(), authMethod) {
+		return errInvalidClientMetadata, errors.New("token endpoint auth method is not supported")
+	}
+
+	if authMethod == appModel.AuthMethodNone && contains(grantTypes, "client_credentials") {
+		return errInvalidClientMetadata, errors.New("client_credentials grant type requires client authentication")
+	}
+
+	if authMethod == appModel.AuthMethodPrivateKeyJWT {
+		if err := validateJWKS(metadata.JWKS); err != nil {
+			return errInvalidClientMetadata, err
+		}
+	}
+
+	if len(metadata.IDTokenSignedResponseAlg) > 0 && !h.isSupportedSigningAlgorithm(metadata.IDTokenSignedResponseAlg) {
+		return errInvalidClientMetadata, errors.New("id token signing algorithm is not supported")
+	}
+
+	scopes := strings.Fields(metadata.Scope)
+	for _, scope := range scopes {
+		if !h.isRegisteredScope(scope) {
+			return errInvalidClientMetadata, errors.New("scope " + scope + " is not registered")
+		}
+
+		if !trusted && !h.isSelfRegistrableScope(scope) && !app.IsAllowedScope(scope) {
+			return errInvalidClientMetadata, errors.New("scope " + scope + " requires an initial access token")
+		}
+	}
+
+	if len(metadata.LogoURI) > 0 {
+		if u, err := url.Parse(metadata.LogoURI); err != nil || !u.IsAbs() {
+			return errInvalidClientMetadata, errors.New("invalid logo uri")
+		}
+	}
+
+	switch authMethod {
+	case appModel.AuthMethodNone, appModel.AuthMethodPrivateKeyJWT:
+		app.ClientSecret = ""
+	default:
+		if len(app.ClientSecret) <= 0 {
+			clientSecret, err := appSecurity.GenerateRandomToken(32)
+			if err != nil {
+				return errServerError, errors.New("error generate client secret")
+			}
+
+			app.ClientSecret = clientSecret
+		}
+	}
+
+	app.Name = metadata.ClientName
+	app.RedirectURIs = metadata.RedirectURIs
+	app.Public = authMethod == appModel.AuthMethodNone
+	app.Scopes = scopes
+	app.IDTokenSignedResponseAlg = metadata.IDTokenSignedResponseAlg
+	app.TokenEndpointAuthMethod = authMethod
+	app.JWKS = nil
+	if authMethod == appModel.AuthMethodPrivateKeyJWT {
+		app.JWKS = metadata.JWKS
+	}
+	app.PublicKey = ""
+	app.GrantTypes = grantTypes
+	app.ResponseTypes = responseTypes
+	app.Contacts = metadata.Contacts
+	app.LogoURI = metadata.LogoURI
+
+	return "", nil
+}
+
+// clientInformation return registered metadata of app, client secret is returned to the client itself only
+func (h *Handler) clientInformation(app *appModel.Application) *appModel.ClientInformation {
+	information := &appModel.ClientInformation{
+		ClientID:              app.ClientID,
+		ClientSecret:          app.ClientSecret,
+		ClientSecretExpiresAt: 0,
+		RegistrationClientURI: h.RegistrationEndpoint + "/" + app.ClientID,
+		ClientMetadata: appModel.ClientMetadata{
+			RedirectURIs:             app.RedirectURIs,
+			TokenEndpointAuthMethod:  app.TokenEndpointAuthMethod,
+			GrantTypes:               app.GrantTypes,
+			ResponseTypes:            app.ResponseTypes,
+			ClientName:               app.Name,
+			LogoURI:                  app.LogoURI,
+			Scope:                    strings.Join(app.Scopes, " "),
+			Contacts:                 app.Contacts,
+			JWKS:                     app.JWKS,
+			IDTokenSignedResponseAlg: app.IDTokenSignedResponseAlg,
+		},
+	}
+
+	if !app.CreatedAt.IsZero() {
+		information.ClientIDIssuedAt = app.CreatedAt.Unix()
+	}
+
+	return information
+}
+
+// contains report whether list holds value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
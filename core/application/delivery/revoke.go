@@ -0,0 +1,84 @@
+package delivery
+
+import (
+	"net/http"
+	"time"
+
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// revoker return false when the token is not recognized
+type revoker func(token, clientID string) bool
+
+// RevokeHandler http handler
+// RFC 7009 token revocation, client can only revoke tokens issued to itself
+// localhost:9000/api/oauth2/revoke
+// payload (application/x-www-form-urlencoded):
+// token=3q8CKvGd2wY1Ut0Ukq0bRzq2o6xJf7iP3z9QJ3xF6sM&token_type_hint=refresh_token
+func (h *Handler) RevokeHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeOAuth2Error(res, http.StatusMethodNotAllowed, errInvalidRequest, "invalid method")
+			return
+		}
+
+		app, err := h.authenticateClient(req, true)
+		if err != nil {
+			writeOAuth2Error(res, http.StatusUnauthorized, errInvalidClient, err.Error())
+			return
+		}
+
+		token := req.PostFormValue("token")
+		if len(token) <= 0 {
+			writeOAuth2Error(res, http.StatusBadRequest, errInvalidRequest, "token is required")
+			return
+		}
+
+		revokers := []revoker{h.revokeAccessToken, h.revokeRefreshToken}
+		if req.PostFormValue("token_type_hint") == "refresh_token" {
+			revokers = []revoker{h.revokeRefreshToken, h.revokeAccessToken}
+		}
+
+		for _, revoke := range revokers {
+			if revoke(token, app.ClientID) {
+				break
+			}
+		}
+
+		// invalid or unknown token is not an error, RFC 7009 section 2.2
+		res.Header().Set("Cache-Control", "no-store")
+		res.WriteHeader(http.StatusOK)
+	}
+}
+
+// revokeAccessToken put jwt access token into denylist until it expires
+func (h *Handler) revokeAccessToken(token, clientID string) bool {
+	claims, err := jwtGen.ParseAccessToken(token, h.Keys)
+	if err != nil {
+		return false
+	}
+
+	if claims.ClientID != clientID {
+		return true
+	}
+
+	h.Denylist.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	return true
+}
+
+// revokeRefreshToken revoke refresh token family including the access tokens derived from it
+func (h *Handler) revokeRefreshToken(token, clientID string) bool {
+	output := h.RefreshTokenRepo.FindByToken(token)
+	if output.Error != nil {
+		return false
+	}
+
+	refreshToken := output.Result.(*tokenModel.RefreshToken)
+	if refreshToken.ClientID != clientID {
+		return true
+	}
+
+	h.revokeFamily(refreshToken.FamilyID)
+	return true
+}
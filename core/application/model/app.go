@@ -1,14 +1,143 @@
 package model
 
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// client authentication methods at token endpoint, RFC 7591 section 2
+const (
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+	AuthMethodClientSecretJWT   = "client_secret_jwt"
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"
+	AuthMethodNone              = "none"
+)
+
 // Application struct
+// IDTokenSignedResponseAlg is the jws alg of id_token issued to this application, empty means the server default.
+// Client using private_key_jwt registers its verification keys as JWKS or as PEM encoded PublicKey.
+// Empty GrantTypes allow every grant and response type, for applications created before they were recorded.
+// Only the hash of registration access token (RFC 7592) is kept
 type Application struct {
-	Name         string `json:"name"`
-	ClientID     string `json:"clientId"`
-	ClientSecret string `json:"clientSecret"`
-	RedirectURI  string `json:"redirectUri"`
+	Name                        string    `json:"name"`
+	ClientID                    string    `json:"clientId"`
+	ClientSecret                string    `json:"clientSecret"`
+	RedirectURIs                []string  `json:"redirectUris"`
+	Public                      bool      `json:"public"`
+	Scopes                      []string  `json:"scopes"`
+	IDTokenSignedResponseAlg    string    `json:"idTokenSignedResponseAlg"`
+	TokenEndpointAuthMethod     string    `json:"tokenEndpointAuthMethod"`
+	JWKS                        *key.JWKS `json:"jwks,omitempty"`
+	PublicKey                   string    `json:"publicKey,omitempty"`
+	GrantTypes                  []string  `json:"grantTypes,omitempty"`
+	ResponseTypes               []string  `json:"responseTypes,omitempty"`
+	Contacts                    []string  `json:"contacts,omitempty"`
+	LogoURI                     string    `json:"logoUri,omitempty"`
+	RegistrationAccessTokenHash string    `json:"registrationAccessTokenHash,omitempty"`
+	CreatedAt                   time.Time `json:"createdAt"`
 }
 
 // IsValidClientSecret function
 func (a *Application) IsValidClientSecret(clientSecret string) bool {
 	return a.ClientSecret == clientSecret
 }
+
+// IsPublic function
+// public client (native or browser app) can not keep a secret, it must use PKCE instead
+func (a *Application) IsPublic() bool {
+	return a.Public
+}
+
+// IsJWTAuthentication function
+// client registered with private_key_jwt or client_secret_jwt must authenticate with signed assertion only
+func (a *Application) IsJWTAuthentication() bool {
+	return a.TokenEndpointAuthMethod == AuthMethodPrivateKeyJWT || a.TokenEndpointAuthMethod == AuthMethodClientSecretJWT
+}
+
+// VerificationKey function
+// return registered public key verifying private_key_jwt assertion signed with kid
+func (a *Application) VerificationKey(kid string) (crypto.PublicKey, error) {
+	if a.JWKS != nil {
+		jwk, err := a.JWKS.Key(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		return jwk.PublicKey()
+	}
+
+	if len(a.PublicKey) > 0 {
+		return key.ParsePublicKeyFromPEM([]byte(a.PublicKey))
+	}
+
+	return nil, errors.New("application has no registered public key")
+}
+
+// IsAllowedGrantType function
+// refresh token is issued together with authorization code, so it is allowed with authorization_code grant
+func (a *Application) IsAllowedGrantType(grantType string) bool {
+	if len(a.GrantTypes) <= 0 {
+		return true
+	}
+
+	for _, g := range a.GrantTypes {
+		if g == grantType || (grantType == "refresh_token" && g == "authorization_code") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAllowedResponseType function
+func (a *Application) IsAllowedResponseType(responseType string) bool {
+	if len(a.ResponseTypes) <= 0 {
+		return true
+	}
+
+	for _, r := range a.ResponseTypes {
+		if r == responseType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetRegistrationAccessToken function
+func (a *Application) SetRegistrationAccessToken(token string) {
+	a.RegistrationAccessTokenHash = hashToken(token)
+}
+
+// IsValidRegistrationAccessToken function
+func (a *Application) IsValidRegistrationAccessToken(token string) bool {
+	if len(a.RegistrationAccessTokenHash) <= 0 || len(token) <= 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(a.RegistrationAccessTokenHash)) == 1
+}
+
+// hashToken return hex encoded sha256 of token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAllowedScope function
+func (a *Application) IsAllowedScope(scope string) bool {
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
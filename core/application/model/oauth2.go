@@ -1,11 +1,37 @@
 package model
 
+import (
+	"strings"
+)
+
 // OAuth2 struct
 type OAuth2 struct {
-	GrantType    string   `json:"grant_type"`
-	Code         string   `json:"code"`
-	RedirectURI  string   `json:"redirect_uri"`
-	ClientID     string   `json:"client_id"`
-	ClientSecret string   `json:"client_secret"`
-	Scopes       []string `json:"scopes"`
+	GrantType           string   `json:"grant_type"`
+	Code                string   `json:"code"`
+	RedirectURI         string   `json:"redirect_uri"`
+	ClientID            string   `json:"client_id"`
+	ClientSecret        string   `json:"client_secret"`
+	ClientAssertionType string   `json:"client_assertion_type"`
+	ClientAssertion     string   `json:"client_assertion"`
+	Scopes              []string `json:"scopes"`
+	Scope               string   `json:"scope"`
+	RefreshToken        string   `json:"refresh_token"`
+	CodeVerifier        string   `json:"code_verifier"`
+}
+
+// RequestedScopes function
+// merge space delimited scope parameter with scopes list
+func (o *OAuth2) RequestedScopes() []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range append(strings.Fields(o.Scope), o.Scopes...) {
+		if len(scope) <= 0 || seen[scope] {
+			continue
+		}
+
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	return scopes
 }
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrInvalidRedirectURI returned when redirect uri is not absolute or has fragment
+	ErrInvalidRedirectURI = errors.New("redirect uri must be absolute and without fragment")
+
+	// ErrRedirectURIRequired returned when redirect uri is omitted and more than one is registered
+	ErrRedirectURIRequired = errors.New("redirect uri is required")
+
+	// ErrRedirectURIMismatch returned when redirect uri is not registered for the application
+	ErrRedirectURIMismatch = errors.New("redirect uri is not registered for this application")
+)
+
+// ValidateRedirectURI function
+// redirect uri must be absolute and must not include fragment, RFC 6749 section 3.1.2
+func ValidateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || len(u.Fragment) > 0 || strings.Contains(uri, "#") {
+		return ErrInvalidRedirectURI
+	}
+
+	return nil
+}
+
+// MatchRedirectURI function
+// return redirect uri to send the response to, requested uri must equal a registered one,
+// except for native app loopback uri which may use any port (RFC 8252 section 7.3).
+// Omitted uri defaults to the registered one when exactly one is registered
+func (a *Application) MatchRedirectURI(requested string) (string, error) {
+	if len(requested) <= 0 {
+		if len(a.RedirectURIs) == 1 {
+			return a.RedirectURIs[0], nil
+		}
+
+		return "", ErrRedirectURIRequired
+	}
+
+	if err := ValidateRedirectURI(requested); err != nil {
+		return "", err
+	}
+
+	requestedURL, _ := url.Parse(requested)
+	for _, registered := range a.RedirectURIs {
+		if registered == requested {
+			return requested, nil
+		}
+
+		registeredURL, err := url.Parse(registered)
+		if err == nil && isLoopbackMatch(registeredURL, requestedURL) {
+			return requested, nil
+		}
+	}
+
+	return "", ErrRedirectURIMismatch
+}
+
+// isLoopbackMatch report whether both uri are http on the same loopback ip literal with same path and query,
+// port is ignored because native app listens on an ephemeral port
+func isLoopbackMatch(registered, requested *url.URL) bool {
+	if registered.Scheme != "http" || requested.Scheme != "http" || requested.User != nil {
+		return false
+	}
+
+	host := registered.Hostname()
+	if host != "127.0.0.1" && host != "::1" {
+		return false
+	}
+
+	return requested.Hostname() == host && requested.Path == registered.Path && requested.RawQuery == registered.RawQuery
+}
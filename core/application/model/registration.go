@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// ClientMetadata struct
+// RFC 7591 section 2 client metadata
+type ClientMetadata struct {
+	RedirectURIs             []string  `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod  string    `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes               []string  `json:"grant_types,omitempty"`
+	ResponseTypes            []string  `json:"response_types,omitempty"`
+	ClientName               string    `json:"client_name,omitempty"`
+	LogoURI                  string    `json:"logo_uri,omitempty"`
+	Scope                    string    `json:"scope,omitempty"`
+	Contacts                 []string  `json:"contacts,omitempty"`
+	JWKS                     *key.JWKS `json:"jwks,omitempty"`
+	IDTokenSignedResponseAlg string    `json:"id_token_signed_response_alg,omitempty"`
+}
+
+// ClientInformation struct
+// RFC 7591 section 3.2.1 client information response,
+// registration_access_token and registration_client_uri are defined by RFC 7592
+type ClientInformation struct {
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64  `json:"client_id_issued_at,omitempty"`
+	ClientSecretExpiresAt   int64  `json:"client_secret_expires_at"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri,omitempty"`
+	ClientMetadata
+}
+
+// ClientUpdate struct
+// RFC 7592 section 2.2 client update request, metadata replaces the registered values
+type ClientUpdate struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	ClientMetadata
+}
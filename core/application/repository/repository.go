@@ -15,4 +15,5 @@ type Repository interface {
 	Save(*model.Application) Output
 	FindByID(string) Output
 	FindAll() Output
+	Delete(string) Output
 }
@@ -2,28 +2,37 @@ package repository
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/musobarlab/oauth2-go/core/application/model"
 )
 
 // InMemory struct
+// clients are registered and updated by concurrent requests, a changed client is saved as a new value
 type InMemory struct {
+	sync.RWMutex
 	db map[string]*model.Application
 }
 
 // NewInMemory function
 func NewInMemory(db map[string]*model.Application) *InMemory {
-	return &InMemory{db}
+	return &InMemory{db: db}
 }
 
 // Save function
 func (r *InMemory) Save(app *model.Application) Output {
+	r.Lock()
+	defer r.Unlock()
+
 	r.db[app.ClientID] = app
 	return Output{Result: app}
 }
 
 // FindByID function
 func (r *InMemory) FindByID(id string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
 	app, ok := r.db[id]
 	if !ok {
 		return Output{Error: fmt.Errorf("app with id %s, not found", id)}
@@ -34,6 +43,9 @@ func (r *InMemory) FindByID(id string) Output {
 
 // FindAll function
 func (r *InMemory) FindAll() Output {
+	r.RLock()
+	defer r.RUnlock()
+
 	var list []*model.Application
 
 	for _, v := range r.db {
@@ -42,3 +54,17 @@ func (r *InMemory) FindAll() Output {
 
 	return Output{Result: list}
 }
+
+// Delete function
+func (r *InMemory) Delete(id string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	app, ok := r.db[id]
+	if !ok {
+		return Output{Error: fmt.Errorf("app with id %s, not found", id)}
+	}
+
+	delete(r.db, id)
+	return Output{Result: app}
+}
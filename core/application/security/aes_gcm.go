@@ -0,0 +1,70 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// AESGCM struct
+// authenticated encryption, a tampered ciphertext fails to decrypt instead of decrypting to garbage,
+// use it for anything handed to the browser
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM Security AESGCM's constructor
+// AES-256 key is derived from secret with sha256, so secret may have any length, it should have at least 32 random bytes
+func NewAESGCM(secret string) (*AESGCM, error) {
+	if len(secret) < 16 {
+		return nil, errors.New("secret is too short")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// Encrypt string to base64 crypto using AES-GCM, random nonce is prepended to the ciphertext
+func (a *AESGCM) Encrypt(text string) (string, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := a.aead.Seal(nonce, nonce, []byte(text), nil)
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt from base64 to decrypted string
+func (a *AESGCM) Decrypt(encryptedText string) (string, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encryptedText)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ciphertext) < a.aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	nonce := ciphertext[:a.aead.NonceSize()]
+	plaintext, err := a.aead.Open(nil, nonce, ciphertext[a.aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
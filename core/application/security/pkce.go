@@ -0,0 +1,64 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	// CodeChallengeMethodPlain PKCE plain transformation
+	CodeChallengeMethodPlain = "plain"
+
+	// CodeChallengeMethodS256 PKCE S256 transformation
+	CodeChallengeMethodS256 = "S256"
+)
+
+// IsValidCodeChallengeMethod return true if method is supported by this server
+func IsValidCodeChallengeMethod(method string) bool {
+	return method == CodeChallengeMethodPlain || method == CodeChallengeMethodS256
+}
+
+// IsValidCodeVerifier validate code verifier as described in RFC 7636 section 4.1
+// 43-128 characters from [A-Z] / [a-z] / [0-9] / "-" / "." / "_" / "~"
+func IsValidCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// VerifyCodeChallenge compare code verifier from token request with code challenge from authorize request
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+	if !IsValidCodeVerifier(verifier) {
+		return false
+	}
+
+	var computed string
+	switch method {
+	case CodeChallengeMethodS256:
+		computed = S256CodeChallenge(verifier)
+	case CodeChallengeMethodPlain:
+		computed = verifier
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// S256CodeChallenge return S256 code challenge of verifier, BASE64URL(SHA256(verifier)), RFC 7636 section 4.2
+func S256CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
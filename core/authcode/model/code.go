@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// AuthorizationCode struct
+// code is single use, tokens issued from it share FamilyID so they can be revoked when the code is replayed,
+// redeemed code is kept until KeepUntil so a replay after ExpiredAt is still detected
+type AuthorizationCode struct {
+	Code                string    `json:"code"`
+	ClientID            string    `json:"clientId"`
+	UserID              string    `json:"userId"`
+	RedirectURI         string    `json:"redirectUri"`
+	CodeChallenge       string    `json:"codeChallenge"`
+	CodeChallengeMethod string    `json:"codeChallengeMethod"`
+	Scopes              []string  `json:"scopes"`
+	Nonce               string    `json:"nonce"`
+	AuthTime            time.Time `json:"authTime"`
+	FamilyID            string    `json:"familyId"`
+	Redeemed            bool      `json:"redeemed"`
+	KeepUntil           time.Time `json:"keepUntil"`
+	ExpiredAt           time.Time `json:"expiredAt"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// IsExpired function
+func (c *AuthorizationCode) IsExpired() bool {
+	return time.Now().After(c.ExpiredAt)
+}
+
+// IsStale function
+// return true when the code is no longer needed, unredeemed code once expired and redeemed code after KeepUntil
+func (c *AuthorizationCode) IsStale() bool {
+	if c.Redeemed && time.Now().Before(c.KeepUntil) {
+		return false
+	}
+
+	return c.IsExpired()
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/core/authcode/model"
+)
+
+// ErrCodeRedeemed returned by Redeem when the code has already been exchanged
+var ErrCodeRedeemed = errors.New("authorization code has already been redeemed")
+
+// Output struct
+type Output struct {
+	Result interface{}
+	Error  error
+}
+
+// Repository interface
+type Repository interface {
+	Save(*model.AuthorizationCode) Output
+	Redeem(code string, keepUntil time.Time) Output
+}
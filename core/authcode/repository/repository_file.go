@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/core/authcode/model"
+)
+
+// File struct
+// persist authorization codes as json file, codes survive server restart
+type File struct {
+	sync.Mutex
+	path string
+	db   map[string]*model.AuthorizationCode
+}
+
+// NewFile function
+func NewFile(path string) (*File, error) {
+	r := &File{path: path, db: make(map[string]*model.AuthorizationCode)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &r.db); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Save function
+func (r *File) Save(code *model.AuthorizationCode) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	r.db[code.Code] = code
+	if err := r.flush(); err != nil {
+		return Output{Error: err}
+	}
+
+	return Output{Result: code}
+}
+
+// Redeem function
+// burn the code and keep it until keepUntil, a code that has already been redeemed returns ErrCodeRedeemed
+func (r *File) Redeem(code string, keepUntil time.Time) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	authCode, ok := r.db[code]
+	if !ok {
+		return Output{Error: fmt.Errorf("authorization code not found")}
+	}
+
+	if authCode.Redeemed {
+		return Output{Result: authCode, Error: ErrCodeRedeemed}
+	}
+
+	authCode.Redeemed = true
+	authCode.KeepUntil = keepUntil
+	if err := r.flush(); err != nil {
+		authCode.Redeemed = false
+		authCode.KeepUntil = time.Time{}
+		return Output{Error: err}
+	}
+
+	return Output{Result: authCode}
+}
+
+// flush write whole db to a temporary file then replace the old one
+func (r *File) flush() error {
+	// drop stale codes so the file does not grow forever
+	for k, v := range r.db {
+		if v.IsStale() {
+			delete(r.db, k)
+		}
+	}
+
+	data, err := json.Marshal(r.db)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/core/authcode/model"
+)
+
+// InMemory struct
+type InMemory struct {
+	sync.Mutex
+	db map[string]*model.AuthorizationCode
+}
+
+// NewInMemory function
+func NewInMemory(db map[string]*model.AuthorizationCode) *InMemory {
+	return &InMemory{db: db}
+}
+
+// Save function
+func (r *InMemory) Save(code *model.AuthorizationCode) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	// drop stale codes so the map does not grow forever
+	for k, v := range r.db {
+		if v.IsStale() {
+			delete(r.db, k)
+		}
+	}
+
+	r.db[code.Code] = code
+	return Output{Result: code}
+}
+
+// Redeem function
+// burn the code and keep it until keepUntil, a code that has already been redeemed returns ErrCodeRedeemed
+func (r *InMemory) Redeem(code string, keepUntil time.Time) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	authCode, ok := r.db[code]
+	if !ok {
+		return Output{Error: fmt.Errorf("authorization code not found")}
+	}
+
+	if authCode.Redeemed {
+		return Output{Result: authCode, Error: ErrCodeRedeemed}
+	}
+
+	authCode.Redeemed = true
+	authCode.KeepUntil = keepUntil
+	return Output{Result: authCode}
+}
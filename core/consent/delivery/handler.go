@@ -0,0 +1,213 @@
+package delivery
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appRepo "github.com/musobarlab/oauth2-go/core/application/repository"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	consentModel "github.com/musobarlab/oauth2-go/core/consent/model"
+	consentRepo "github.com/musobarlab/oauth2-go/core/consent/repository"
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
+	userModel "github.com/musobarlab/oauth2-go/core/user/model"
+	userRepo "github.com/musobarlab/oauth2-go/core/user/repository"
+)
+
+// Handler struct
+type Handler struct {
+	ConsentRepo      consentRepo.Repository
+	AppRepo          appRepo.Repository
+	UserRepo         userRepo.Repository
+	RefreshTokenRepo tokenRepo.Repository
+	Denylist         tokenRepo.Denylist
+	CookieCipher     appSecurity.Interface
+}
+
+// csrfTokenAge how long the consent list form can be posted
+const csrfTokenAge = time.Hour
+
+// consentView struct
+// consent with the client name shown to the user
+type consentView struct {
+	ClientID   string
+	ClientName string
+	Scopes     []string
+	UpdatedAt  time.Time
+}
+
+// ListConsentHandler http handler
+// show clients the logged in user has granted access to
+func (h *Handler) ListConsentHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var tmpl *template.Template
+
+		message := struct {
+			Data      []consentView
+			CSRFToken string
+			Message   string
+		}{}
+
+		userID, ok := h.loggedInUser(req)
+		if !ok {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "you should login first"
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		output := h.ConsentRepo.FindByUser(userID)
+		if output.Error != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = output.Error.Error()
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		for _, consent := range output.Result.([]*consentModel.Consent) {
+			view := consentView{
+				ClientID:   consent.ClientID,
+				ClientName: consent.ClientID,
+				Scopes:     consent.Scopes,
+				UpdatedAt:  consent.UpdatedAt,
+			}
+
+			if outputApp := h.AppRepo.FindByID(consent.ClientID); outputApp.Error == nil {
+				view.ClientName = outputApp.Result.(*appModel.Application).Name
+			}
+
+			message.Data = append(message.Data, view)
+		}
+
+		csrfToken, err := h.csrfToken(userID)
+		if err != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "error create form token"
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		message.CSRFToken = csrfToken
+		res.Header().Set("Cache-Control", "no-store")
+		tmpl = template.Must(template.ParseFiles("./static/list_consent.html"))
+		tmpl.Execute(res, message)
+	}
+}
+
+// RevokeConsentHandler http handler
+// withdraw consent given to a client, tokens the client holds for the user are revoked as well,
+// csrf_token of the consent list page is required so other sites can not post the form
+// payload (application/x-www-form-urlencoded): client_id=58a1a940-5432-4046-8e54-18059f070ebd&csrf_token=...
+func (h *Handler) RevokeConsentHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var tmpl *template.Template
+
+		message := struct {
+			Message string
+		}{
+			Message: "invalid method",
+		}
+
+		if req.Method != http.MethodPost {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			tmpl.Execute(res, message)
+			return
+		}
+
+		userID, ok := h.loggedInUser(req)
+		if !ok {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "you should login first"
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		if !h.isValidCSRFToken(userID, req.PostFormValue("csrf_token")) {
+			res.WriteHeader(http.StatusForbidden)
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "invalid form token, please reload the page"
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		clientID := req.PostFormValue("client_id")
+		output := h.ConsentRepo.Delete(userID, clientID)
+		if output.Error != nil {
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = output.Error.Error()
+
+			tmpl.Execute(res, message)
+			return
+		}
+
+		h.revokeTokens(userID, clientID)
+
+		http.Redirect(res, req, "/consents", http.StatusSeeOther)
+	}
+}
+
+// loggedInUser return id of user from login cookie
+func (h *Handler) loggedInUser(req *http.Request) (string, bool) {
+	session, err := userModel.SessionFromRequest(h.CookieCipher, req)
+	if err != nil {
+		return "", false
+	}
+
+	if output := h.UserRepo.FindByID(session.UserID); output.Error != nil {
+		return "", false
+	}
+
+	return session.UserID, true
+}
+
+// csrfToken seal user id and issue time, token can not be made without the cookie secret
+func (h *Handler) csrfToken(userID string) (string, error) {
+	return h.CookieCipher.Encrypt("csrf " + userID + " " + strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// isValidCSRFToken return true when token was issued to the user within csrfTokenAge
+func (h *Handler) isValidCSRFToken(userID, token string) bool {
+	if len(token) <= 0 {
+		return false
+	}
+
+	plaintext, err := h.CookieCipher.Decrypt(token)
+	if err != nil {
+		return false
+	}
+
+	parts := strings.Fields(plaintext)
+	if len(parts) != 3 || parts[0] != "csrf" || parts[1] != userID {
+		return false
+	}
+
+	issuedAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(time.Unix(issuedAt, 0)) < csrfTokenAge
+}
+
+// revokeTokens revoke refresh tokens of the user issued to the client and the access tokens issued with them
+func (h *Handler) revokeTokens(userID, clientID string) {
+	output := h.RefreshTokenRepo.RevokeByClient(userID, clientID)
+	if output.Error != nil {
+		return
+	}
+
+	for _, refreshToken := range output.Result.([]*tokenModel.RefreshToken) {
+		if len(refreshToken.AccessTokenID) > 0 {
+			h.Denylist.Revoke(refreshToken.AccessTokenID, refreshToken.AccessTokenExpiredAt)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// Consent struct
+// scopes a user has granted to a client, returning users are not asked again for scopes it covers
+type Consent struct {
+	UserID    string    `json:"userId"`
+	ClientID  string    `json:"clientId"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Covers return true when every scope has already been granted
+func (c *Consent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !c.HasScope(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasScope function
+func (c *Consent) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Grant add scopes to the granted ones
+func (c *Consent) Grant(scopes []string) {
+	for _, scope := range scopes {
+		if !c.HasScope(scope) {
+			c.Scopes = append(c.Scopes, scope)
+		}
+	}
+
+	c.UpdatedAt = time.Now()
+}
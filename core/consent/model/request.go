@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// Request struct
+// validated authorization request waiting for the user decision on consent page,
+// the page only carries Challenge so the request can not be altered by the browser
+type Request struct {
+	Challenge            string    `json:"challenge"`
+	UserID               string    `json:"userId"`
+	ClientID             string    `json:"clientId"`
+	RedirectURI          string    `json:"redirectUri"`
+	RequestedRedirectURI string    `json:"requestedRedirectUri"`
+	State                string    `json:"state"`
+	Scopes               []string  `json:"scopes"`
+	Nonce                string    `json:"nonce"`
+	CodeChallenge        string    `json:"codeChallenge"`
+	CodeChallengeMethod  string    `json:"codeChallengeMethod"`
+	AuthTime             time.Time `json:"authTime"`
+	ExpiredAt            time.Time `json:"expiredAt"`
+}
+
+// IsExpired function
+func (r *Request) IsExpired() bool {
+	return time.Now().After(r.ExpiredAt)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"github.com/musobarlab/oauth2-go/core/consent/model"
+)
+
+// Output struct
+type Output struct {
+	Result interface{}
+	Error  error
+}
+
+// Repository interface
+// consent is identified by user id and client id
+type Repository interface {
+	Save(*model.Consent) Output
+	Find(userID, clientID string) Output
+	FindByUser(userID string) Output
+	Delete(userID, clientID string) Output
+}
+
+// RequestRepository interface
+// hold authorization request until the user allows or denies it, Take return the request once
+type RequestRepository interface {
+	Save(*model.Request) Output
+	Take(challenge string) Output
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/musobarlab/oauth2-go/core/consent/model"
+)
+
+// InMemory struct
+type InMemory struct {
+	sync.RWMutex
+	db map[string]*model.Consent
+}
+
+// NewInMemory function
+func NewInMemory(db map[string]*model.Consent) *InMemory {
+	return &InMemory{db: db}
+}
+
+func consentKey(userID, clientID string) string {
+	return userID + ":" + clientID
+}
+
+// Save function
+func (r *InMemory) Save(consent *model.Consent) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	r.db[consentKey(consent.UserID, consent.ClientID)] = consent
+	return Output{Result: consent}
+}
+
+// Find function
+func (r *InMemory) Find(userID, clientID string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	consent, ok := r.db[consentKey(userID, clientID)]
+	if !ok {
+		return Output{Error: fmt.Errorf("consent of client %s, not found", clientID)}
+	}
+
+	return Output{Result: consent}
+}
+
+// FindByUser function
+// most recently updated first
+func (r *InMemory) FindByUser(userID string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	var list []*model.Consent
+	for _, v := range r.db {
+		if v.UserID == userID {
+			list = append(list, v)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+
+	return Output{Result: list}
+}
+
+// Delete function
+func (r *InMemory) Delete(userID, clientID string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	key := consentKey(userID, clientID)
+	consent, ok := r.db[key]
+	if !ok {
+		return Output{Error: fmt.Errorf("consent of client %s, not found", clientID)}
+	}
+
+	delete(r.db, key)
+	return Output{Result: consent}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/musobarlab/oauth2-go/core/consent/model"
+)
+
+// RequestInMemory struct
+type RequestInMemory struct {
+	sync.Mutex
+	db map[string]*model.Request
+}
+
+// NewRequestInMemory function
+func NewRequestInMemory(db map[string]*model.Request) *RequestInMemory {
+	return &RequestInMemory{db: db}
+}
+
+// Save function
+func (r *RequestInMemory) Save(request *model.Request) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	// drop requests the user walked away from
+	for k, v := range r.db {
+		if v.IsExpired() {
+			delete(r.db, k)
+		}
+	}
+
+	r.db[request.Challenge] = request
+	return Output{Result: request}
+}
+
+// Take function
+// remove and return the request, so one consent page answers one authorization request
+func (r *RequestInMemory) Take(challenge string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	request, ok := r.db[challenge]
+	if !ok {
+		return Output{Error: fmt.Errorf("consent request not found")}
+	}
+
+	delete(r.db, challenge)
+	if request.IsExpired() {
+		return Output{Error: fmt.Errorf("consent request has expired")}
+	}
+
+	return Output{Result: request}
+}
@@ -0,0 +1,103 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/musobarlab/oauth2-go/core/discovery/model"
+)
+
+// endpoint names as advertised in metadata
+const (
+	AuthorizationEndpoint = "authorization_endpoint"
+	TokenEndpoint         = "token_endpoint"
+	UserInfoEndpoint      = "userinfo_endpoint"
+	JWKSURI               = "jwks_uri"
+	RegistrationEndpoint  = "registration_endpoint"
+	IntrospectionEndpoint = "introspection_endpoint"
+	RevocationEndpoint    = "revocation_endpoint"
+)
+
+// Handler struct
+// endpoints are recorded by HandleFunc, so metadata only advertises routes that are actually served,
+// routes are registered on Mux
+type Handler struct {
+	Mux                           *http.ServeMux
+	Issuer                        string
+	GrantTypes                    []string
+	ResponseTypes                 []string
+	Scopes                        []string
+	TokenEndpointAuthMethods      []string
+	EndpointAuthMethods           []string
+	EndpointAuthSigningAlgorithms []string
+	SigningAlgorithms             []string
+	CodeChallengeMethods          []string
+	Claims                        []string
+	endpoints                     map[string]string
+}
+
+// HandleFunc register handler for pattern on Mux and advertise it as endpoint
+func (h *Handler) HandleFunc(endpoint, pattern string, handler http.HandlerFunc) {
+	if h.endpoints == nil {
+		h.endpoints = make(map[string]string)
+	}
+
+	h.endpoints[endpoint] = strings.TrimRight(h.Issuer, "/") + pattern
+	h.Mux.HandleFunc(pattern, handler)
+}
+
+// Metadata function
+func (h *Handler) Metadata() *model.Metadata {
+	return &model.Metadata{
+		Issuer:                            h.Issuer,
+		AuthorizationEndpoint:             h.endpoints[AuthorizationEndpoint],
+		TokenEndpoint:                     h.endpoints[TokenEndpoint],
+		UserInfoEndpoint:                  h.endpoints[UserInfoEndpoint],
+		JWKSURI:                           h.endpoints[JWKSURI],
+		RegistrationEndpoint:              h.endpoints[RegistrationEndpoint],
+		IntrospectionEndpoint:             h.endpoints[IntrospectionEndpoint],
+		RevocationEndpoint:                h.endpoints[RevocationEndpoint],
+		ScopesSupported:                   h.Scopes,
+		ResponseTypesSupported:            h.ResponseTypes,
+		GrantTypesSupported:               h.GrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  h.SigningAlgorithms,
+		TokenEndpointAuthMethodsSupported: h.TokenEndpointAuthMethods,
+		TokenEndpointAuthSigningAlgValuesSupported:         h.EndpointAuthSigningAlgorithms,
+		IntrospectionEndpointAuthMethodsSupported:          h.EndpointAuthMethods,
+		IntrospectionEndpointAuthSigningAlgValuesSupported: h.EndpointAuthSigningAlgorithms,
+		RevocationEndpointAuthMethodsSupported:             h.EndpointAuthMethods,
+		RevocationEndpointAuthSigningAlgValuesSupported:    h.EndpointAuthSigningAlgorithms,
+		CodeChallengeMethodsSupported:                      h.CodeChallengeMethods,
+		ClaimsSupported:                                    h.Claims,
+	}
+}
+
+// AuthorizationServerHandler http handler
+// RFC 8414 /.well-known/oauth-authorization-server
+func (h *Handler) AuthorizationServerHandler() http.HandlerFunc {
+	return h.metadataHandler()
+}
+
+// OpenIDConfigurationHandler http handler
+// OpenID Connect Discovery /.well-known/openid-configuration
+func (h *Handler) OpenIDConfigurationHandler() http.HandlerFunc {
+	return h.metadataHandler()
+}
+
+func (h *Handler) metadataHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			res.Header().Set("Allow", "GET")
+			res.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, _ := json.Marshal(h.Metadata())
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Access-Control-Allow-Origin", "*")
+		res.WriteHeader(200)
+		res.Write(payload)
+	}
+}
@@ -0,0 +1,89 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// supported jws algorithms
+const (
+	RS256 = "RS256"
+	PS256 = "PS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+const rsaKeySize = 2048
+
+// IsSupportedAlgorithm function
+func IsSupportedAlgorithm(alg string) bool {
+	switch alg {
+	case RS256, PS256, ES256, EdDSA:
+		return true
+	}
+
+	return false
+}
+
+// SigningMethod function
+// return jwt signing method for alg
+func SigningMethod(alg string) (jwt.SigningMethod, error) {
+	if !IsSupportedAlgorithm(alg) {
+		return nil, fmt.Errorf("algorithm %s is not supported", alg)
+	}
+
+	return jwt.GetSigningMethod(alg), nil
+}
+
+// IsCompatible function
+// report whether public key can verify signatures of alg, guards against algorithm confusion
+func IsCompatible(pub crypto.PublicKey, alg string) bool {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return alg == RS256 || alg == PS256
+	case *ecdsa.PublicKey:
+		return alg == ES256 && k.Curve == elliptic.P256()
+	case ed25519.PublicKey:
+		return alg == EdDSA
+	}
+
+	return false
+}
+
+// DefaultAlgorithm function
+// return algorithm matching the private key type
+func DefaultAlgorithm(signer crypto.Signer) (string, error) {
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		return RS256, nil
+	case *ecdsa.PublicKey:
+		return ES256, nil
+	case ed25519.PublicKey:
+		return EdDSA, nil
+	}
+
+	return "", fmt.Errorf("unsupported key type %T", signer)
+}
+
+// GenerateKey function
+// generate private key for alg
+func GenerateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case RS256, PS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+
+	return nil, fmt.Errorf("algorithm %s is not supported", alg)
+}
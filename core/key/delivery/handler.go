@@ -0,0 +1,33 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// Handler struct
+type Handler struct {
+	KeyManager *key.Manager
+}
+
+// JWKSHandler http handler
+// publish public signing keys as RFC 7517 json web key set
+// localhost:9000/.well-known/jwks.json
+func (h *Handler) JWKSHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			res.Header().Set("Allow", "GET")
+			res.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, _ := json.Marshal(h.KeyManager.JWKS())
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Access-Control-Allow-Origin", "*")
+		res.Header().Set("Cache-Control", "public, max-age=300")
+		res.WriteHeader(200)
+		res.Write(payload)
+	}
+}
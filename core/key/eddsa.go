@@ -0,0 +1,58 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA RFC 8037 EdDSA signing method with Ed25519 keys,
+// jwt-go v3 does not ship one so it is registered here
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+var errEdDSAVerification = errors.New("eddsa: verification error")
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg function
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Sign function, key must be ed25519.PrivateKey
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	signature := ed25519.Sign(privateKey, []byte(signingString))
+	return base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify function, key must be ed25519.PublicKey
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return errEdDSAVerification
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// JWK struct
+// RFC 7517 json web key, public part only
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS struct
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// errUnsupportedKey returned for key type that can not be represented as jwk
+var errUnsupportedKey = errors.New("unsupported key type")
+
+// Key function
+// return key with kid, key without kid is selected only when the set holds a single key
+func (s *JWKS) Key(kid string) (*JWK, error) {
+	if len(kid) <= 0 && len(s.Keys) == 1 {
+		return &s.Keys[0], nil
+	}
+
+	for i := range s.Keys {
+		if len(kid) > 0 && s.Keys[i].Kid == kid {
+			return &s.Keys[i], nil
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// PublicKey function
+// parse RSA, EC P-256 and OKP Ed25519 jwk into public key
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) <= 0 || !exponent.IsInt64() || exponent.Int64() <= 1 || exponent.Int64() > 1<<31-1 {
+			return nil, errUnsupportedKey
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, errUnsupportedKey
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return pub, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errUnsupportedKey
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errUnsupportedKey
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, errUnsupportedKey
+}
+
+// NewJWK function for converting public key to signing jwk
+func NewJWK(pub crypto.PublicKey, alg string) (JWK, error) {
+	jwk, err := thumbprintMembers(pub)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk.Use = "sig"
+	jwk.Alg = alg
+	jwk.Kid, err = KeyID(pub)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	return jwk, nil
+}
+
+// KeyID function
+// stable kid derived from RFC 7638 jwk thumbprint, same key always gets the same kid
+func KeyID(pub crypto.PublicKey) (string, error) {
+	jwk, err := thumbprintMembers(pub)
+	if err != nil {
+		return "", err
+	}
+
+	// json encodes struct fields in declaration order, JWK fields are not in lexicographic order
+	// so the required members are copied into a map, which json encodes with sorted keys
+	members := map[string]string{"kty": jwk.Kty}
+	switch jwk.Kty {
+	case "RSA":
+		members["n"] = jwk.N
+		members["e"] = jwk.E
+	case "EC":
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+		members["y"] = jwk.Y
+	case "OKP":
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+	}
+
+	thumbprintInput, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(thumbprintInput)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// thumbprintMembers return jwk with key type specific members only
+func thumbprintMembers(pub crypto.PublicKey) (JWK, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, errUnsupportedKey
+		}
+
+		return JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(padLeft(k.X.Bytes(), 32)),
+			Y:   base64.RawURLEncoding.EncodeToString(padLeft(k.Y.Bytes(), 32)),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	}
+
+	return JWK{}, errUnsupportedKey
+}
+
+// padLeft pad big endian integer to fixed size coordinate, RFC 7518 section 6.2.1.2
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -0,0 +1,421 @@
+package key
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State of signing key
+type State string
+
+const (
+	// StatePending key is published in jwks but not used for signing yet
+	StatePending State = "pending"
+
+	// StateActive key is used for signing new tokens
+	StateActive State = "active"
+
+	// StateRetiring key is no longer used for signing but still verifies tokens it signed
+	StateRetiring State = "retiring"
+
+	// StateRetired key is neither published nor accepted
+	StateRetired State = "retired"
+)
+
+// ErrKeyNotFound returned when no usable key matches the kid
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// Key struct
+type Key struct {
+	ID        string
+	Algorithm string
+	State     State
+	Signer    crypto.Signer
+	CreatedAt time.Time
+	RetireAt  time.Time
+}
+
+// PublicKeyProvider interface
+// look up verification key by kid from jwt header
+type PublicKeyProvider interface {
+	PublicKey(kid string) (crypto.PublicKey, error)
+}
+
+// Manager struct
+// hold signing keys through their life cycle pending -> active -> retiring -> retired,
+// retiring key keeps verifying until every token it signed has expired (retireAfter).
+// Every enabled algorithm has its own active key, tokens are signed with defaultAlgorithm
+// unless a client asks for another one. Keys are kept in store when it is set
+type Manager struct {
+	sync.RWMutex
+	keys             []*Key
+	defaultAlgorithm string
+	retireAfter      time.Duration
+	store            Store
+}
+
+// NewManager function
+// privateKey becomes the active key of its default algorithm (RS256, ES256 or EdDSA) which is
+// used for signing, a pending key is generated for the next rotation
+func NewManager(privateKey crypto.Signer, retireAfter time.Duration) (*Manager, error) {
+	alg, err := DefaultAlgorithm(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewManagerWithAlgorithm(privateKey, alg, retireAfter)
+}
+
+// NewManagerWithAlgorithm function
+// same as NewManager with explicit algorithm, e.g. PS256 for rsa key
+func NewManagerWithAlgorithm(privateKey crypto.Signer, alg string, retireAfter time.Duration) (*Manager, error) {
+	if !IsSupportedAlgorithm(alg) || !IsCompatible(privateKey.Public(), alg) {
+		return nil, fmt.Errorf("key of type %T can not be used with %s", privateKey, alg)
+	}
+
+	m := &Manager{
+		defaultAlgorithm: alg,
+		retireAfter:      retireAfter,
+	}
+
+	if err := m.add(privateKey, alg); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// UseStore function
+// keys found in store replace the keys of their algorithm, so the keys rotated before restart stay in use,
+// algorithms the store does not know yet keep their keys, then every change is saved to store
+func (m *Manager) UseStore(store Store) error {
+	stored, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	storedAlgorithms := make(map[string]bool)
+	for _, k := range stored {
+		if IsSupportedAlgorithm(k.Algorithm) {
+			storedAlgorithms[k.Algorithm] = true
+		}
+	}
+
+	var keys []*Key
+	for _, k := range stored {
+		if storedAlgorithms[k.Algorithm] {
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range m.keys {
+		if !storedAlgorithms[k.Algorithm] {
+			keys = append(keys, k)
+		}
+	}
+
+	m.keys = keys
+	m.store = store
+	m.sweep(time.Now())
+	return m.save()
+}
+
+// EnableAlgorithm function
+// generate active and pending key for alg, so clients may request tokens signed with it
+func (m *Manager) EnableAlgorithm(alg string) error {
+	if !IsSupportedAlgorithm(alg) {
+		return fmt.Errorf("algorithm %s is not supported", alg)
+	}
+
+	if m.SigningKey(alg) != nil {
+		return nil
+	}
+
+	privateKey, err := GenerateKey(alg)
+	if err != nil {
+		return err
+	}
+
+	return m.add(privateKey, alg)
+}
+
+// EnableAlgorithmWithKey function
+// same as EnableAlgorithm with privateKey as the active key, e.g. loaded from PEM file,
+// algorithm that already has an active key (loaded from store) keeps it
+func (m *Manager) EnableAlgorithmWithKey(privateKey crypto.Signer, alg string) error {
+	if !IsSupportedAlgorithm(alg) || !IsCompatible(privateKey.Public(), alg) {
+		return fmt.Errorf("key of type %T can not be used with %s", privateKey, alg)
+	}
+
+	if m.SigningKey(alg) != nil {
+		return nil
+	}
+
+	return m.add(privateKey, alg)
+}
+
+// add privateKey as active key of alg together with a new pending key
+func (m *Manager) add(privateKey crypto.Signer, alg string) error {
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return err
+	}
+
+	pending, err := m.generate(alg)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.keys = append(m.keys, &Key{
+		ID:        kid,
+		Algorithm: alg,
+		State:     StateActive,
+		Signer:    privateKey,
+		CreatedAt: time.Now(),
+	}, pending)
+
+	return m.save()
+}
+
+// generate new pending key for alg
+func (m *Manager) generate(alg string) (*Key, error) {
+	privateKey, err := GenerateKey(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:        kid,
+		Algorithm: alg,
+		State:     StatePending,
+		Signer:    privateKey,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Rotate function
+// for every algorithm, pending key becomes active, active key starts retiring and a new pending key is generated
+func (m *Manager) Rotate() error {
+	var pendings []*Key
+	for _, alg := range m.Algorithms() {
+		pending, err := m.generate(alg)
+		if err != nil {
+			return err
+		}
+
+		pendings = append(pendings, pending)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	for _, k := range m.keys {
+		switch k.State {
+		case StateActive:
+			k.State = StateRetiring
+			k.RetireAt = now.Add(m.retireAfter)
+		case StatePending:
+			k.State = StateActive
+		}
+	}
+
+	m.keys = append(m.keys, pendings...)
+	m.sweep(now)
+	return m.save()
+}
+
+// StartRotation function
+// rotate keys every interval and retire expired keys in between, call returned function to stop
+func (m *Manager) StartRotation(interval time.Duration) func() {
+	done := make(chan struct{})
+	rotation := time.NewTicker(interval)
+
+	// retiring keys are swept more often than they are rotated
+	sweepInterval := interval
+	if m.retireAfter > 0 && m.retireAfter < sweepInterval {
+		sweepInterval = m.retireAfter
+	}
+	sweep := time.NewTicker(sweepInterval)
+
+	go func() {
+		defer rotation.Stop()
+		defer sweep.Stop()
+
+		for {
+			select {
+			case <-rotation.C:
+				m.Rotate()
+			case now := <-sweep.C:
+				m.retire(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// retire sweep keys and save the change, failed save is retried by the next change
+func (m *Manager) retire(now time.Time) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.sweep(now) {
+		m.save()
+	}
+}
+
+// sweep retire keys whose tokens have all expired and forget retired keys, caller must hold the lock.
+// Return true when a key was removed
+func (m *Manager) sweep(now time.Time) bool {
+	var keys []*Key
+	for _, k := range m.keys {
+		if k.State == StateRetiring && now.After(k.RetireAt) {
+			k.State = StateRetired
+		}
+
+		if k.State != StateRetired {
+			keys = append(keys, k)
+		}
+	}
+
+	removed := len(keys) != len(m.keys)
+	m.keys = keys
+	return removed
+}
+
+// save write keys to store, caller must hold the lock
+func (m *Manager) save() error {
+	if m.store == nil {
+		return nil
+	}
+
+	return m.store.Save(m.keys)
+}
+
+// DefaultAlgorithm function
+func (m *Manager) DefaultAlgorithm() string {
+	return m.defaultAlgorithm
+}
+
+// Algorithms function
+// return every enabled algorithm, default algorithm first
+func (m *Manager) Algorithms() []string {
+	m.RLock()
+	defer m.RUnlock()
+
+	seen := map[string]bool{m.defaultAlgorithm: true}
+	var others []string
+	for _, k := range m.keys {
+		if !seen[k.Algorithm] {
+			seen[k.Algorithm] = true
+			others = append(others, k.Algorithm)
+		}
+	}
+
+	sort.Strings(others)
+	return append([]string{m.defaultAlgorithm}, others...)
+}
+
+// SigningKey function
+// return active key of alg, empty alg selects the default algorithm
+func (m *Manager) SigningKey(alg string) *Key {
+	if len(alg) <= 0 {
+		alg = m.defaultAlgorithm
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, k := range m.keys {
+		if k.State == StateActive && k.Algorithm == alg {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// PublicKey function
+// return public key of active or retiring key by kid, token without kid is verified with the default active key
+func (m *Manager) PublicKey(kid string) (crypto.PublicKey, error) {
+	if len(kid) <= 0 {
+		if k := m.SigningKey(""); k != nil {
+			return k.Signer.Public(), nil
+		}
+		return nil, ErrKeyNotFound
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	now := time.Now()
+	for _, k := range m.keys {
+		if k.ID != kid {
+			continue
+		}
+
+		if k.State == StateActive || (k.State == StateRetiring && now.Before(k.RetireAt)) {
+			return k.Signer.Public(), nil
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// Keys function
+// return copy of every pending, active and retiring key
+func (m *Manager) Keys() []Key {
+	m.RLock()
+	defer m.RUnlock()
+
+	keys := make([]Key, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, *k)
+	}
+
+	return keys
+}
+
+// JWKS function
+// publish pending keys ahead of activation, so verifiers already know them when they start signing,
+// and retiring keys until their tokens expire, expired keys are swept here too when rotation is not scheduled
+func (m *Manager) JWKS() JWKS {
+	m.retire(time.Now())
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, k := range m.Keys() {
+		if k.State == StateRetired {
+			continue
+		}
+
+		jwk, err := NewJWK(k.Signer.Public(), k.Algorithm)
+		if err != nil {
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
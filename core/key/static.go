@@ -0,0 +1,44 @@
+package key
+
+import (
+	"crypto"
+)
+
+// StaticKeySet struct
+// fixed set of public keys, for resource server verifying with keys loaded from disk
+type StaticKeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// NewStaticKeySet function
+// accept *rsa.PublicKey, *ecdsa.PublicKey (P-256) and ed25519.PublicKey
+func NewStaticKeySet(publicKeys ...crypto.PublicKey) (*StaticKeySet, error) {
+	keys := make(map[string]crypto.PublicKey)
+	for _, pub := range publicKeys {
+		kid, err := KeyID(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[kid] = pub
+	}
+
+	return &StaticKeySet{keys: keys}, nil
+}
+
+// PublicKey function
+// token without kid is accepted only when the set holds a single key
+func (s *StaticKeySet) PublicKey(kid string) (crypto.PublicKey, error) {
+	if len(kid) <= 0 && len(s.keys) == 1 {
+		for _, pub := range s.keys {
+			return pub, nil
+		}
+	}
+
+	pub, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return pub, nil
+}
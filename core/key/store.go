@@ -0,0 +1,172 @@
+package key
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store interface
+// persist keys of Manager, so rotated keys and their state survive restart
+type Store interface {
+	Load() ([]*Key, error)
+	Save(keys []*Key) error
+}
+
+// manifestFile name of the file in key directory holding state of every key
+const manifestFile = "keys.json"
+
+// storedKey data structure
+// manifest entry, private key is kept in <kid>.pem next to the manifest
+type storedKey struct {
+	ID        string    `json:"kid"`
+	Algorithm string    `json:"alg"`
+	State     State     `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	RetireAt  time.Time `json:"retire_at,omitempty"`
+}
+
+// DirStore struct
+// keep every key as PKCS#8 PEM file in a directory together with a json manifest of their state
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore function
+// directory is created when it does not exist
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DirStore{dir: dir}, nil
+}
+
+// Load function
+// return stored keys, empty directory returns no key
+func (s *DirStore) Load() ([]*Key, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var manifest []storedKey
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(manifest))
+	for _, entry := range manifest {
+		pemBytes, err := ioutil.ReadFile(s.keyPath(entry.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		signer, err := ParsePrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", entry.ID, err)
+		}
+
+		kid, err := KeyID(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+
+		if kid != entry.ID || !IsCompatible(signer.Public(), entry.Algorithm) {
+			return nil, fmt.Errorf("key %s does not match its manifest entry", entry.ID)
+		}
+
+		keys = append(keys, &Key{
+			ID:        entry.ID,
+			Algorithm: entry.Algorithm,
+			State:     entry.State,
+			Signer:    signer,
+			CreatedAt: entry.CreatedAt,
+			RetireAt:  entry.RetireAt,
+		})
+	}
+
+	return keys, nil
+}
+
+// Save function
+// write PEM file of new keys, replace the manifest then remove PEM files of keys no longer kept
+func (s *DirStore) Save(keys []*Key) error {
+	manifest := make([]storedKey, 0, len(keys))
+	kept := make(map[string]bool)
+	for _, k := range keys {
+		if _, err := os.Stat(s.keyPath(k.ID)); os.IsNotExist(err) {
+			pemBytes, err := MarshalPrivateKeyToPEM(k.Signer)
+			if err != nil {
+				return err
+			}
+
+			if err := writeFile(s.keyPath(k.ID), pemBytes); err != nil {
+				return err
+			}
+		}
+
+		kept[k.ID+".pem"] = true
+		manifest = append(manifest, storedKey{
+			ID:        k.ID,
+			Algorithm: k.Algorithm,
+			State:     k.State,
+			CreatedAt: k.CreatedAt,
+			RetireAt:  k.RetireAt,
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(s.dir, manifestFile), data); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if !kept[filepath.Base(f)] {
+			os.Remove(f)
+		}
+	}
+
+	return nil
+}
+
+// keyPath function
+func (s *DirStore) keyPath(kid string) string {
+	return filepath.Join(s.dir, kid+".pem")
+}
+
+// writeFile write data to a temporary file readable by owner only then replace the old one
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,74 @@
+package model
+
+// Scope struct
+// Description is shown to the user on consent page, Default scopes are granted when client requests no scope,
+// Claims are user claims released to id_token and userinfo when the scope is granted
+type Scope struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Default     bool     `json:"default"`
+	Claims      []string `json:"claims"`
+}
+
+// StandardScopes return OpenID Connect scopes, OpenID Connect Core 1.0 section 5.4
+func StandardScopes() []*Scope {
+	return []*Scope{
+		{Name: "openid", Description: "Sign you in with your account"},
+		{Name: "profile", Description: "Read your name", Claims: []string{"name"}},
+		{Name: "email", Description: "Read your email address", Claims: []string{"email", "email_verified"}},
+	}
+}
+
+// IsOpenIDConnect return true when scope is defined by OpenID Connect,
+// every client may request these, the user decides on consent page
+func IsOpenIDConnect(name string) bool {
+	for _, s := range StandardScopes() {
+		if s.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValidName report whether name is a valid scope-token, RFC 6749 section 3.3
+func IsValidName(name string) bool {
+	if len(name) <= 0 {
+		return false
+	}
+
+	for _, c := range name {
+		if c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Claims return claims released by scopes
+func Claims(scopes []*Scope) []string {
+	var claims []string
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		for _, claim := range scope.Claims {
+			if !seen[claim] {
+				seen[claim] = true
+				claims = append(claims, claim)
+			}
+		}
+	}
+
+	return claims
+}
+
+// HasScope report whether scopes hold scope
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
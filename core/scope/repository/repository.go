@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"github.com/musobarlab/oauth2-go/core/scope/model"
+)
+
+// Output struct
+type Output struct {
+	Result interface{}
+	Error  error
+}
+
+// Repository interface
+// scope registry, scopes are identified by name
+type Repository interface {
+	Save(*model.Scope) Output
+	FindByName(string) Output
+	FindByNames([]string) Output
+	FindAll() Output
+}
+
+// Claims function
+// return user claims released by the scopes registered in repo
+func Claims(repo Repository, scopes []string) []string {
+	output := repo.FindByNames(scopes)
+	if output.Error != nil {
+		return nil
+	}
+
+	return model.Claims(output.Result.([]*model.Scope))
+}
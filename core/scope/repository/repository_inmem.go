@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/musobarlab/oauth2-go/core/scope/model"
+)
+
+// InMemory struct
+type InMemory struct {
+	sync.RWMutex
+	db map[string]*model.Scope
+}
+
+// NewInMemory function
+func NewInMemory(db map[string]*model.Scope) *InMemory {
+	return &InMemory{db: db}
+}
+
+// Save function
+func (r *InMemory) Save(scope *model.Scope) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	r.db[scope.Name] = scope
+	return Output{Result: scope}
+}
+
+// FindByName function
+func (r *InMemory) FindByName(name string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	scope, ok := r.db[name]
+	if !ok {
+		return Output{Error: fmt.Errorf("scope %s, not found", name)}
+	}
+
+	return Output{Result: scope}
+}
+
+// FindByNames function
+// return registered scopes in the order of names, unknown names are skipped
+func (r *InMemory) FindByNames(names []string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	var list []*model.Scope
+	for _, name := range names {
+		if scope, ok := r.db[name]; ok {
+			list = append(list, scope)
+		}
+	}
+
+	return Output{Result: list}
+}
+
+// FindAll function
+// sorted by name
+func (r *InMemory) FindAll() Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	var list []*model.Scope
+	for _, v := range r.db {
+		list = append(list, v)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return Output{Result: list}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken struct
+// refresh tokens are opaque, every rotation issues a new token within the same family,
+// AccessTokenID is jti of the access token issued together with this refresh token
+type RefreshToken struct {
+	Token                string    `json:"token"`
+	FamilyID             string    `json:"familyId"`
+	ClientID             string    `json:"clientId"`
+	UserID               string    `json:"userId"`
+	Scopes               []string  `json:"scopes"`
+	AuthTime             time.Time `json:"authTime"`
+	AccessTokenID        string    `json:"accessTokenId"`
+	AccessTokenExpiredAt time.Time `json:"accessTokenExpiredAt"`
+	Rotated              bool      `json:"rotated"`
+	Revoked              bool      `json:"revoked"`
+	ExpiredAt            time.Time `json:"expiredAt"`
+	CreatedAt            time.Time `json:"createdAt"`
+}
+
+// IsExpired function
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiredAt)
+}
+
+// Copy function
+// return a copy that does not share Scopes with t
+func (t *RefreshToken) Copy() *RefreshToken {
+	c := *t
+	c.Scopes = append([]string(nil), t.Scopes...)
+	return &c
+}
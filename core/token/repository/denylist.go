@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"time"
+)
+
+// Denylist interface
+// hold jti of revoked jwt until the jwt expires
+type Denylist interface {
+	Revoke(jti string, expiredAt time.Time) Output
+	IsRevoked(jti string) bool
+}
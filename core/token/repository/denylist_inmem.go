@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// DenylistInMemory struct
+type DenylistInMemory struct {
+	sync.RWMutex
+	db map[string]time.Time
+}
+
+// NewDenylistInMemory function
+func NewDenylistInMemory(db map[string]time.Time) *DenylistInMemory {
+	return &DenylistInMemory{db: db}
+}
+
+// Revoke function
+func (r *DenylistInMemory) Revoke(jti string, expiredAt time.Time) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	// expired jwt is rejected anyway, no need to keep it
+	now := time.Now()
+	for k, v := range r.db {
+		if now.After(v) {
+			delete(r.db, k)
+		}
+	}
+
+	r.db[jti] = expiredAt
+	return Output{Result: jti}
+}
+
+// IsRevoked function
+func (r *DenylistInMemory) IsRevoked(jti string) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	_, ok := r.db[jti]
+	return ok
+}
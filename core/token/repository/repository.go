@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/musobarlab/oauth2-go/core/token/model"
+)
+
+var (
+	// ErrRefreshTokenReused returned by Rotate when the refresh token has already been rotated
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+	// ErrRefreshTokenRevoked returned by Rotate when the refresh token has been revoked
+	ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+)
+
+// Output struct
+type Output struct {
+	Result interface{}
+	Error  error
+}
+
+// Repository interface
+type Repository interface {
+	Save(*model.RefreshToken) Output
+	FindByToken(string) Output
+	Rotate(string) Output
+	RevokeFamily(string) Output
+	RevokeByClient(userID, clientID string) Output
+	RevokeClient(clientID string) Output
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/musobarlab/oauth2-go/core/token/model"
+)
+
+// InMemory struct
+type InMemory struct {
+	sync.RWMutex
+	db map[string]*model.RefreshToken
+}
+
+// NewInMemory function
+func NewInMemory(db map[string]*model.RefreshToken) *InMemory {
+	return &InMemory{db: db}
+}
+
+// Save function
+func (r *InMemory) Save(token *model.RefreshToken) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	r.db[token.Token] = token
+	return Output{Result: token}
+}
+
+// FindByToken function
+// return a copy, stored token is changed by Rotate and revocation while the caller reads it
+func (r *InMemory) FindByToken(token string) Output {
+	r.RLock()
+	defer r.RUnlock()
+
+	refreshToken, ok := r.db[token]
+	if !ok {
+		return Output{Error: fmt.Errorf("refresh token not found")}
+	}
+
+	return Output{Result: refreshToken.Copy()}
+}
+
+// Rotate function
+// mark refresh token as used, a token that has already been rotated returns ErrRefreshTokenReused,
+// a token revoked since it was read returns ErrRefreshTokenRevoked
+func (r *InMemory) Rotate(token string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	refreshToken, ok := r.db[token]
+	if !ok {
+		return Output{Error: fmt.Errorf("refresh token not found")}
+	}
+
+	if refreshToken.Rotated {
+		return Output{Result: refreshToken.Copy(), Error: ErrRefreshTokenReused}
+	}
+
+	if refreshToken.Revoked {
+		return Output{Result: refreshToken.Copy(), Error: ErrRefreshTokenRevoked}
+	}
+
+	refreshToken.Rotated = true
+	return Output{Result: refreshToken.Copy()}
+}
+
+// RevokeFamily function
+// revoke every refresh token issued within the family
+func (r *InMemory) RevokeFamily(familyID string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	var list []*model.RefreshToken
+	for _, v := range r.db {
+		if v.FamilyID == familyID {
+			v.Revoked = true
+			list = append(list, v.Copy())
+		}
+	}
+
+	return Output{Result: list}
+}
+
+// RevokeByClient function
+// revoke every refresh token the user has granted to the client
+func (r *InMemory) RevokeByClient(userID, clientID string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	var list []*model.RefreshToken
+	for _, v := range r.db {
+		if v.UserID == userID && v.ClientID == clientID && !v.Revoked {
+			v.Revoked = true
+			list = append(list, v.Copy())
+		}
+	}
+
+	return Output{Result: list}
+}
+
+// RevokeClient function
+// revoke every refresh token issued to the client, used when the client is deleted
+func (r *InMemory) RevokeClient(clientID string) Output {
+	r.Lock()
+	defer r.Unlock()
+
+	var list []*model.RefreshToken
+	for _, v := range r.db {
+		if v.ClientID == clientID && !v.Revoked {
+			v.Revoked = true
+			list = append(list, v.Copy())
+		}
+	}
+
+	return Output{Result: list}
+}
@@ -9,15 +9,25 @@ import (
 
 	"github.com/satori/go.uuid"
 
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	"github.com/musobarlab/oauth2-go/core/key"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
 	userModel "github.com/musobarlab/oauth2-go/core/user/model"
 	userRepo "github.com/musobarlab/oauth2-go/core/user/repository"
 	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/middleware"
 )
 
 // Handler struct
 type Handler struct {
 	UserRepo             userRepo.Repository
 	AccessTokenGenerator jwtGen.AccessTokenGenerator
+	Issuer               string
+	Keys                 key.PublicKeyProvider
+	Denylist             tokenRepo.Denylist
+	ScopeRepo            scopeRepo.Repository
+	CookieCipher         appSecurity.Interface
 }
 
 // GetLogin function
@@ -93,12 +103,23 @@ func (h *Handler) PostLogin() http.HandlerFunc {
 			return
 		}
 
-		// set cookie
+		// set cookie, user id and login time are sealed so the browser can not change who is logged in
+
+		now := time.Now()
+		session, err := userModel.SealSession(h.CookieCipher, &userModel.Session{UserID: userRes.ID, AuthTime: now})
+		if err != nil {
+			res.WriteHeader(500)
+			tmpl = template.Must(template.ParseFiles("./static/error.html"))
+			message.Message = "error create session"
+			tmpl.Execute(res, message)
+			return
+		}
 
 		http.SetCookie(res, &http.Cookie{
-			Name:    "user_id",
-			Value:   userRes.ID,
-			Expires: time.Now().Add(10 * time.Minute),
+			Name:     userModel.SessionCookie,
+			Value:    session,
+			Expires:  now.Add(userModel.SessionAge),
+			HttpOnly: true,
 		})
 
 		tmpl = template.Must(template.ParseFiles("./static/index.html"))
@@ -138,7 +159,7 @@ func (h *Handler) CreateUser() http.HandlerFunc {
 			return
 		}
 
-		payload, _ := json.Marshal(user)
+		payload, _ := json.Marshal(user.Profile())
 
 		res.Header().Add("Content-Type", "application/json")
 		res.WriteHeader(201)
@@ -149,7 +170,13 @@ func (h *Handler) CreateUser() http.HandlerFunc {
 // Me function
 func (h *Handler) Me() http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		userID := req.Header.Get("userId")
+		userID, ok := middleware.SubjectFromContext(req.Context())
+		if !ok {
+			res.Header().Add("Content-Type", "application/json")
+			res.WriteHeader(401)
+			res.Write([]byte(`{"success": false, "code": 401, "message": "token is required"}`))
+			return
+		}
 
 		output := h.UserRepo.FindByID(userID)
 		if output.Error != nil {
@@ -170,7 +197,7 @@ func (h *Handler) Me() http.HandlerFunc {
 			Success: true,
 			Code:    "200",
 			Message: "get me",
-			Data:    userRes,
+			Data:    userRes.Profile(),
 		}
 
 		payload, _ := json.Marshal(userPayload)
@@ -207,9 +234,10 @@ func (h *Handler) Auth() http.HandlerFunc {
 			return
 		}
 
+		// token is not issued to a client, it is meant for the api of this server
 		claim := jwtGen.Claim{
-			Issuer:   "wuriyanto.com",
-			Audience: userRes.ID,
+			Issuer:   h.Issuer,
+			Audience: h.Issuer,
 			Subject:  userRes.ID,
 			Email:    userRes.Email,
 		}
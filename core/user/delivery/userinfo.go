@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
+	userModel "github.com/musobarlab/oauth2-go/core/user/model"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/middleware"
+)
+
+const userInfoRealm = "userinfo"
+
+// UserInfo http handler
+// OpenID Connect UserInfo endpoint, return claims released by the access token scopes
+// access token is accepted from Authorization header, access_token form body or query parameter
+// localhost:9000/userinfo
+func (h *Handler) UserInfo() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodPost {
+			res.Header().Set("Allow", "GET, POST")
+			res.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		accessToken, err := middleware.BearerToken(req, true)
+		if err == middleware.ErrNoToken {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, userInfoRealm, "", "", "")
+			return
+		}
+
+		if err != nil {
+			middleware.WriteBearerError(res, http.StatusBadRequest, userInfoRealm, middleware.ErrorInvalidRequest, err.Error(), "")
+			return
+		}
+
+		claims, err := jwtGen.ParseAccessToken(accessToken, h.Keys)
+		if err != nil || h.Denylist.IsRevoked(claims.Id) {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, userInfoRealm, middleware.ErrorInvalidToken, "the access token is not valid", "")
+			return
+		}
+
+		scopes := claims.Scopes()
+		if !scopeModel.HasScope(scopes, "openid") {
+			middleware.WriteBearerError(res, http.StatusForbidden, userInfoRealm, middleware.ErrorInsufficientScope, "openid scope is required", "openid")
+			return
+		}
+
+		output := h.UserRepo.FindByID(claims.Subject)
+		if output.Error != nil {
+			middleware.WriteBearerError(res, http.StatusUnauthorized, userInfoRealm, middleware.ErrorInvalidToken, "the access token is not valid", "")
+			return
+		}
+
+		userRes := output.Result.(*userModel.User)
+
+		userInfo := userRes.Claims(scopeRepo.Claims(h.ScopeRepo, scopes))
+		userInfo["sub"] = userRes.ID
+
+		payload, _ := json.Marshal(userInfo)
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Cache-Control", "no-store")
+		res.WriteHeader(200)
+		res.Write(payload)
+	}
+}
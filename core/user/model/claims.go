@@ -0,0 +1,19 @@
+package model
+
+// Claims function
+// return OpenID Connect standard claims of the user, only claims named are released
+func (u *User) Claims(names []string) map[string]interface{} {
+	claims := make(map[string]interface{})
+	for _, name := range names {
+		switch name {
+		case "name":
+			claims["name"] = u.Name
+		case "email":
+			claims["email"] = u.Email
+		case "email_verified":
+			claims["email_verified"] = false
+		}
+	}
+
+	return claims
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+)
+
+const (
+	// SessionCookie name of login cookie
+	SessionCookie = "session"
+
+	// SessionAge how long a login is accepted
+	SessionAge = 10 * time.Minute
+)
+
+// Session struct
+// logged in user and the time of login, AuthTime is the auth_time claim of OpenID Connect id_token
+type Session struct {
+	UserID   string
+	AuthTime time.Time
+}
+
+// SealSession function
+// encrypt user id together with login time, so the browser can neither forge nor edit who is logged in
+func SealSession(cipher appSecurity.Interface, session *Session) (string, error) {
+	return cipher.Encrypt(session.UserID + " " + strconv.FormatInt(session.AuthTime.Unix(), 10))
+}
+
+// OpenSession function
+// return session sealed by SealSession, session older than SessionAge is rejected
+func OpenSession(cipher appSecurity.Interface, sealed string) (*Session, error) {
+	plaintext, err := cipher.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Fields(plaintext)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid session")
+	}
+
+	t, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	authTime := time.Unix(t, 0)
+	if time.Since(authTime) > SessionAge {
+		return nil, errors.New("session expired")
+	}
+
+	return &Session{UserID: parts[0], AuthTime: authTime}, nil
+}
+
+// SessionFromRequest function
+// return session of the login cookie of req
+func SessionFromRequest(cipher appSecurity.Interface, req *http.Request) (*Session, error) {
+	c, err := req.Cookie(SessionCookie)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenSession(cipher, c.Value)
+}
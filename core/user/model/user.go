@@ -12,3 +12,16 @@ type User struct {
 func (u *User) IsValidPassword(password string) bool {
 	return u.Password == password
 }
+
+// Profile struct
+// user as returned by the api, password is never sent back
+type Profile struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Profile function
+func (u *User) Profile() *Profile {
+	return &Profile{ID: u.ID, Name: u.Name, Email: u.Email}
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// IDTokenClaim data structure
+// Claims hold user claims released by the granted scopes (profile, email),
+// Algorithm is the client id_token_signed_response_alg, empty means the server default
+type IDTokenClaim struct {
+	Issuer    string
+	Subject   string
+	Audience  string
+	Nonce     string
+	AuthTime  time.Time
+	Algorithm string
+	Claims    map[string]interface{}
+}
+
+// IDTokenResponse data structure
+type IDTokenResponse struct {
+	Error   error
+	IDToken string
+}
+
+// IDTokenGenerator interface abstraction
+type IDTokenGenerator interface {
+	GenerateIDToken(cl IDTokenClaim) <-chan IDTokenResponse
+}
+
+// NewIDTokenGenerator function for initializing OpenID Connect id_token generator
+func NewIDTokenGenerator(keys SigningKeyProvider, tokenAge time.Duration) IDTokenGenerator {
+	return &jwtGenerator{
+		keys:     keys,
+		tokenAge: tokenAge,
+	}
+}
+
+// GenerateIDToken function for generating OpenID Connect id_token
+func (j *jwtGenerator) GenerateIDToken(cl IDTokenClaim) <-chan IDTokenResponse {
+	result := make(chan IDTokenResponse)
+	go func() {
+		defer close(result)
+
+		now := time.Now()
+		age := now.Add(j.tokenAge)
+
+		signingKey := j.keys.SigningKey(cl.Algorithm)
+		if signingKey == nil {
+			result <- IDTokenResponse{Error: key.ErrKeyNotFound}
+			return
+		}
+
+		method, err := key.SigningMethod(signingKey.Algorithm)
+		if err != nil {
+			result <- IDTokenResponse{Error: err}
+			return
+		}
+
+		token := jwt.New(method)
+		token.Header["kid"] = signingKey.ID
+		claims := make(jwt.MapClaims)
+		for k, v := range cl.Claims {
+			claims[k] = v
+		}
+
+		claims["iss"] = cl.Issuer
+		claims["sub"] = cl.Subject
+		claims["aud"] = cl.Audience
+		claims["exp"] = age.Unix()
+		claims["iat"] = now.Unix()
+
+		if !cl.AuthTime.IsZero() {
+			claims["auth_time"] = cl.AuthTime.Unix()
+		}
+
+		if len(cl.Nonce) > 0 {
+			claims["nonce"] = cl.Nonce
+		}
+
+		token.Claims = claims
+
+		tokenString, err := token.SignedString(signingKey.Signer)
+		if err != nil {
+			result <- IDTokenResponse{Error: err}
+			return
+		}
+		result <- IDTokenResponse{Error: nil, IDToken: tokenString}
+	}()
+
+	return result
+}
@@ -1,22 +1,31 @@
 package token
 
 import (
-	"crypto/rsa"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/satori/go.uuid"
+
+	"github.com/musobarlab/oauth2-go/core/key"
 )
 
 // Claim data structure
+// token issued to machine (client credentials) has Subject equal to ClientID and no Email,
+// Audience is the client the token is issued to, random ID (jti) is generated when it is empty
 type Claim struct {
+	ID       string
 	Issuer   string
 	Audience string
 	Subject  string
 	Email    string
+	ClientID string
+	Scopes   []string
 }
 
 // AccessToken data structure
 type AccessToken struct {
+	ID          string
 	AccessToken string
 	ExpiredAt   time.Time
 }
@@ -27,9 +36,15 @@ type AccessTokenResponse struct {
 	AccessToken AccessToken
 }
 
+// SigningKeyProvider interface
+// return the active key of alg new tokens are signed with, empty alg selects the server default
+type SigningKeyProvider interface {
+	SigningKey(alg string) *key.Key
+}
+
 // jwtGenerator private data structure
 type jwtGenerator struct {
-	signKey  *rsa.PrivateKey
+	keys     SigningKeyProvider
 	tokenAge time.Duration
 }
 
@@ -39,9 +54,9 @@ type AccessTokenGenerator interface {
 }
 
 // NewJwtGenerator function for initializing jwtGenerator object
-func NewJwtGenerator(signKey *rsa.PrivateKey, tokenAge time.Duration) AccessTokenGenerator {
+func NewJwtGenerator(keys SigningKeyProvider, tokenAge time.Duration) AccessTokenGenerator {
 	return &jwtGenerator{
-		signKey:  signKey,
+		keys:     keys,
 		tokenAge: tokenAge,
 	}
 }
@@ -55,22 +70,54 @@ func (j *jwtGenerator) GenerateAccessToken(cl Claim) <-chan AccessTokenResponse
 		now := time.Now()
 		age := now.Add(j.tokenAge)
 
-		token := jwt.New(jwt.SigningMethodRS256)
+		id := cl.ID
+		if len(id) <= 0 {
+			id = uuid.NewV4().String()
+		}
+
+		signingKey := j.keys.SigningKey("")
+		if signingKey == nil {
+			result <- AccessTokenResponse{Error: key.ErrKeyNotFound}
+			return
+		}
+
+		method, err := key.SigningMethod(signingKey.Algorithm)
+		if err != nil {
+			result <- AccessTokenResponse{Error: err}
+			return
+		}
+
+		token := jwt.New(method)
+		token.Header["kid"] = signingKey.ID
+		token.Header["typ"] = AccessTokenType
 		claims := make(jwt.MapClaims)
+		claims["jti"] = id
 		claims["iss"] = cl.Issuer
 		claims["aud"] = cl.Audience
 		claims["exp"] = age.Unix()
 		claims["iat"] = now.Unix()
 		claims["sub"] = cl.Subject
-		claims["email"] = cl.Email
+
+		if len(cl.Email) > 0 {
+			claims["email"] = cl.Email
+		}
+
+		if len(cl.ClientID) > 0 {
+			claims["client_id"] = cl.ClientID
+		}
+
+		if len(cl.Scopes) > 0 {
+			claims["scope"] = strings.Join(cl.Scopes, " ")
+		}
+
 		token.Claims = claims
 
-		tokenString, err := token.SignedString(j.signKey)
+		tokenString, err := token.SignedString(signingKey.Signer)
 		if err != nil {
 			result <- AccessTokenResponse{Error: err}
 			return
 		}
-		result <- AccessTokenResponse{Error: nil, AccessToken: AccessToken{AccessToken: tokenString, ExpiredAt: age}}
+		result <- AccessTokenResponse{Error: nil, AccessToken: AccessToken{ID: id, AccessToken: tokenString, ExpiredAt: age}}
 	}()
 
 	return result
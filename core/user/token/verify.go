@@ -0,0 +1,87 @@
+package token
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+)
+
+// AccessTokenType typ header of access token, RFC 9068,
+// id_token is signed with the same keys and must not be accepted as access token
+const AccessTokenType = "at+jwt"
+
+// AccessTokenClaims data structure
+// claims of access token issued by jwtGenerator
+type AccessTokenClaims struct {
+	jwt.StandardClaims
+	Email    string `json:"email,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+// Scopes function
+func (c *AccessTokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// KeyFunc function
+// select verification key by kid from jwt header,
+// the key type must match alg so a token can not choose how it is verified
+func KeyFunc(keys key.PublicKeyProvider) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		alg := token.Method.Alg()
+		if !key.IsSupportedAlgorithm(alg) {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		pub, err := keys.PublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if !key.IsCompatible(pub, alg) {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		return pub, nil
+	}
+}
+
+// AccessTokenKeyFunc function
+// KeyFunc that accept only access token, it must have the at+jwt typ header and a jti to be revocable
+func AccessTokenKeyFunc(keys key.PublicKeyProvider) jwt.Keyfunc {
+	keyFunc := KeyFunc(keys)
+	return func(token *jwt.Token) (interface{}, error) {
+		typ, _ := token.Header["typ"].(string)
+		typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+		if typ != AccessTokenType {
+			return nil, errors.New("token is not an access token")
+		}
+
+		if claims, ok := token.Claims.(*AccessTokenClaims); !ok || len(claims.Id) <= 0 {
+			return nil, errors.New("token is not an access token")
+		}
+
+		return keyFunc(token)
+	}
+}
+
+// ParseAccessToken function for verifying access token signature and expiry
+func ParseAccessToken(tokenString string, keys key.PublicKeyProvider) (*AccessTokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AccessTokenClaims{}, AccessTokenKeyFunc(keys))
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*AccessTokenClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	return claims, nil
+}
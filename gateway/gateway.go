@@ -0,0 +1,256 @@
+// Package gateway put an http service behind login with an oauth2-go server.
+// Browsers run the authorization code flow with PKCE and keep tokens in an encrypted session cookie,
+// API callers send their own bearer token, which is verified and never redirected
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/client"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	"github.com/musobarlab/oauth2-go/middleware"
+	"github.com/musobarlab/oauth2-go/verifier"
+)
+
+// headers sent to the upstream, copies sent by the client are removed.
+// HeaderCSRFToken is sent for browser sessions only, the upstream puts it in its sign out form
+const (
+	HeaderUser        = "X-Forwarded-User"
+	HeaderEmail       = "X-Forwarded-Email"
+	HeaderAccessToken = "X-Forwarded-Access-Token"
+	HeaderCSRFToken   = "X-Forwarded-Csrf-Token"
+)
+
+const (
+	// SignOutPath clear the session and revoke its refresh token, it accepts POST only
+	// with CSRFTokenField of the session, e.g.
+	// <form method="post" action="/oauth2/sign_out"><input type="hidden" name="csrf_token" value="..."></form>
+	SignOutPath = "/oauth2/sign_out"
+
+	// CSRFTokenField form field of sign out carrying the token sent in HeaderCSRFToken
+	CSRFTokenField = "csrf_token"
+
+	defaultSessionAge = 24 * time.Hour
+	realm             = "gateway"
+)
+
+// Config struct
+// gateway is a confidential client of Issuer, RedirectURL must be registered for the client
+// and its path is served by the gateway, e.g. http://localhost:4180/oauth2/callback,
+// Audience lists client ids whose bearer tokens are accepted, the gateway client itself is always accepted
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Upstream     string
+	Scopes       []string
+	Audience     []string
+	CookieSecret string
+	SessionAge   time.Duration
+	HTTPClient   *http.Client
+}
+
+// Gateway struct
+type Gateway struct {
+	config       Config
+	oauth2       *client.Client
+	verifier     *verifier.Verifier
+	cipher       appSecurity.Interface
+	proxy        *httputil.ReverseProxy
+	callbackPath string
+	secureCookie bool
+	refresher    *refresher
+}
+
+// New function for initializing Gateway, issuer metadata and keys are fetched before it returns
+func New(ctx context.Context, config Config) (*Gateway, error) {
+	if len(config.ClientID) <= 0 {
+		return nil, errors.New("client id is required")
+	}
+
+	upstream, err := url.Parse(config.Upstream)
+	if err != nil || !upstream.IsAbs() {
+		return nil, errors.New("upstream must be an absolute url")
+	}
+
+	redirectURL, err := url.Parse(config.RedirectURL)
+	if err != nil || !redirectURL.IsAbs() {
+		return nil, errors.New("redirect url must be an absolute url")
+	}
+
+	cipher, err := appSecurity.NewAESGCM(config.CookieSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SessionAge <= 0 {
+		config.SessionAge = defaultSessionAge
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	audience := append([]string{config.ClientID}, config.Audience...)
+	v, err := verifier.New(ctx, verifier.Config{Issuer: config.Issuer, Audience: audience, HTTPClient: httpClient, Realm: realm})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := v.Metadata()
+	oauth2, err := client.New(ctx, client.Config{
+		Issuer:                metadata.Issuer,
+		ClientID:              config.ClientID,
+		ClientSecret:          config.ClientSecret,
+		RedirectURL:           config.RedirectURL,
+		Scopes:                config.Scopes,
+		AuthorizationEndpoint: metadata.AuthorizationEndpoint,
+		TokenEndpoint:         metadata.TokenEndpoint,
+		RevocationEndpoint:    metadata.RevocationEndpoint,
+		JWKSURI:               metadata.JWKSURI,
+		HTTPClient:            httpClient,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gateway{
+		config:       config,
+		oauth2:       oauth2,
+		verifier:     v,
+		cipher:       cipher,
+		proxy:        httputil.NewSingleHostReverseProxy(upstream),
+		callbackPath: redirectURL.Path,
+		secureCookie: redirectURL.Scheme == "https",
+		refresher:    newRefresher(),
+	}, nil
+}
+
+// ServeHTTP forward authenticated request to the upstream
+func (g *Gateway) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case g.callbackPath:
+		g.callback(res, req)
+		return
+	case SignOutPath:
+		g.signOut(res, req)
+		return
+	}
+
+	// api caller brings its own token, it is verified and never redirected to login
+	if len(req.Header.Get("Authorization")) > 0 {
+		token, err := middleware.BearerToken(req, false)
+		if err != nil {
+			middleware.WriteBearerError(res, http.StatusBadRequest, realm, middleware.ErrorInvalidRequest, err.Error(), "")
+			return
+		}
+
+		claims, err := g.verifier.Verify(req.Context(), token)
+		if err != nil {
+			verifyErr := err.(*middleware.VerifyError)
+			middleware.WriteBearerError(res, verifyErr.Status, realm, verifyErr.Code, verifyErr.Description, verifyErr.Scope)
+			return
+		}
+
+		g.forward(res, req, claims.Subject, claims.Email, token, "")
+		return
+	}
+
+	sess, err := g.loadSession(req)
+	if err != nil {
+		if err == errSessionExpired {
+			g.clearCookie(res, sessionCookie)
+		}
+
+		g.unauthenticated(res, req)
+		return
+	}
+
+	if sess.isTokenExpired() {
+		sess, err = g.refresher.refresh(sess, func(sess *session) (*session, error) {
+			return g.refreshSession(req.Context(), sess)
+		})
+
+		if err != nil {
+			g.clearCookie(res, sessionCookie)
+			g.unauthenticated(res, req)
+			return
+		}
+
+		if err := g.saveSession(res, sess); err != nil {
+			http.Error(res, "error save session", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	g.forward(res, req, sess.Subject, sess.Email, sess.AccessToken, sess.CSRFToken)
+}
+
+// forward proxy request to upstream with the access token and user headers
+func (g *Gateway) forward(res http.ResponseWriter, req *http.Request, subject, email, accessToken, csrfToken string) {
+	req.Header.Del(HeaderUser)
+	req.Header.Del(HeaderEmail)
+	req.Header.Del(HeaderAccessToken)
+	req.Header.Del(HeaderCSRFToken)
+	req.Header.Del(middleware.UserIDHeader)
+	stripCookies(req, sessionCookie, flowCookie)
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set(HeaderUser, subject)
+	req.Header.Set(HeaderAccessToken, accessToken)
+	if len(email) > 0 {
+		req.Header.Set(HeaderEmail, email)
+	}
+
+	if len(csrfToken) > 0 {
+		req.Header.Set(HeaderCSRFToken, csrfToken)
+	}
+
+	g.proxy.ServeHTTP(res, req)
+}
+
+// unauthenticated send browser to login, other callers get 401
+func (g *Gateway) unauthenticated(res http.ResponseWriter, req *http.Request) {
+	if isBrowser(req) {
+		g.startLogin(res, req)
+		return
+	}
+
+	middleware.WriteBearerError(res, http.StatusUnauthorized, realm, "", "", "")
+}
+
+// isBrowser return true for page navigation, xhr and api calls are not redirected
+func isBrowser(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+// stripCookies remove gateway cookies from request sent to the upstream
+func stripCookies(req *http.Request, names ...string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, c := range cookies {
+		keep := true
+		for _, name := range names {
+			if c.Name == name {
+				keep = false
+			}
+		}
+
+		if keep {
+			req.AddCookie(c)
+		}
+	}
+}
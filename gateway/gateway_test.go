@@ -0,0 +1,395 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/client"
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/internal/oauth2test"
+)
+
+const (
+	testUserID       = "user-1"
+	testEmail        = "user-1@example.com"
+	testPassword     = "password-1"
+	testCookieSecret = "0123456789abcdef0123456789abcdef"
+)
+
+// fixture gateway in front of an upstream echoing the request headers, logging in at a real issuer
+type fixture struct {
+	issuer   *oauth2test.Server
+	upstream *httptest.Server
+	server   *httptest.Server
+	gateway  *Gateway
+	clientID string
+	browser  *oauth2test.Browser
+}
+
+func newFixture(t *testing.T, sessionAge time.Duration) *fixture {
+	f := &fixture{issuer: oauth2test.NewServer(t)}
+
+	f.upstream = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(req.Header)
+	}))
+
+	// redirect uri is registered before the gateway serves it
+	f.server = httptest.NewUnstartedServer(nil)
+	serverURL := "http://" + f.server.Listener.Addr().String()
+
+	information := f.issuer.RegisterClient(t, appModel.ClientMetadata{
+		ClientName:              "Gateway",
+		RedirectURIs:            []string{serverURL + "/oauth2/callback"},
+		TokenEndpointAuthMethod: "client_secret_basic",
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		Scope:                   "openid email report.read",
+	})
+	f.clientID = information.ClientID
+
+	gateway, err := New(context.Background(), Config{
+		Issuer:       f.issuer.URL,
+		ClientID:     information.ClientID,
+		ClientSecret: information.ClientSecret,
+		RedirectURL:  serverURL + "/oauth2/callback",
+		Upstream:     f.upstream.URL,
+		Scopes:       []string{"openid", "email", "report.read"},
+		CookieSecret: testCookieSecret,
+		SessionAge:   sessionAge,
+	})
+	if err != nil {
+		t.Fatalf("new gateway: %v", err)
+	}
+
+	f.gateway = gateway
+	f.server.Config.Handler = gateway
+	f.server.Start()
+
+	f.issuer.CreateUser(t, testUserID, testEmail, testPassword)
+	f.browser = f.issuer.NewBrowser(t)
+	f.browser.Login(testEmail, testPassword)
+	return f
+}
+
+func (f *fixture) close() {
+	f.server.Close()
+	f.upstream.Close()
+	f.issuer.Close()
+}
+
+// navigate open path of the gateway as page navigation of the browser
+func (f *fixture) navigate(t *testing.T, path string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, f.server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	req.Header.Set("Accept", "text/html")
+	res, err := f.browser.Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", path, err)
+	}
+
+	return res
+}
+
+// upstreamHeaders return headers the upstream received for the response
+func upstreamHeaders(t *testing.T, res *http.Response) http.Header {
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 from upstream", res.StatusCode)
+	}
+
+	var header http.Header
+	if err := json.NewDecoder(res.Body).Decode(&header); err != nil {
+		t.Fatalf("decode upstream headers: %v", err)
+	}
+
+	return header
+}
+
+// login follow the redirects of the gateway and the issuer like a browser would,
+// return headers the upstream received for path
+func (f *fixture) login(t *testing.T, path string) http.Header {
+	res := f.navigate(t, path)
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound || !strings.HasPrefix(res.Header.Get("Location"), f.issuer.URL+"/get_authorize_user?") {
+		t.Fatalf("unauthenticated page: status %d, location %q", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	callback := f.browser.Authorize(res.Header.Get("Location"))
+	if !strings.HasPrefix(callback, f.server.URL+"/oauth2/callback?") {
+		t.Fatalf("callback = %q", callback)
+	}
+
+	res, err := f.browser.Get(callback)
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != path {
+		t.Fatalf("callback: status %d, location %q", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	return upstreamHeaders(t, f.navigate(t, path))
+}
+
+// session decrypt the session cookie kept by the browser
+func (f *fixture) session(t *testing.T) *session {
+	for _, c := range f.browser.Jar.Cookies(f.serverURL()) {
+		if c.Name != sessionCookie {
+			continue
+		}
+
+		plaintext, err := f.gateway.cipher.Decrypt(c.Value)
+		if err != nil {
+			t.Fatalf("decrypt session: %v", err)
+		}
+
+		var sess session
+		if err := json.Unmarshal([]byte(plaintext), &sess); err != nil {
+			t.Fatalf("decode session: %v", err)
+		}
+
+		return &sess
+	}
+
+	return nil
+}
+
+// setSession replace the session cookie kept by the browser
+func (f *fixture) setSession(t *testing.T, sess *session) {
+	plaintext, err := json.Marshal(sess)
+	if err != nil {
+		t.Fatalf("encode session: %v", err)
+	}
+
+	value, err := f.gateway.cipher.Encrypt(string(plaintext))
+	if err != nil {
+		t.Fatalf("encrypt session: %v", err)
+	}
+
+	f.browser.Jar.SetCookies(f.serverURL(), []*http.Cookie{{Name: sessionCookie, Value: value, Path: "/"}})
+}
+
+func (f *fixture) serverURL() *url.URL {
+	u, _ := url.Parse(f.server.URL)
+	return u
+}
+
+func TestLoginAndProxy(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	header := f.login(t, "/reports?year=2024")
+	if header.Get(HeaderUser) != testUserID || header.Get(HeaderEmail) != testEmail {
+		t.Errorf("user headers = %q %q", header.Get(HeaderUser), header.Get(HeaderEmail))
+	}
+
+	accessToken := header.Get(HeaderAccessToken)
+	if header.Get("Authorization") != "Bearer "+accessToken {
+		t.Errorf("authorization = %q", header.Get("Authorization"))
+	}
+
+	claims, err := f.gateway.verifier.Verify(context.Background(), accessToken)
+	if err != nil || claims.Subject != testUserID {
+		t.Errorf("forwarded access token: %v %+v", err, claims)
+	}
+
+	sess := f.session(t)
+	if sess == nil || len(sess.CSRFToken) <= 0 || header.Get(HeaderCSRFToken) != sess.CSRFToken {
+		t.Fatalf("csrf header = %q, session = %+v", header.Get(HeaderCSRFToken), sess)
+	}
+
+	if age := sess.ExpiresAt.Sub(sess.IssuedAt); age != time.Hour {
+		t.Errorf("session expires %s after it was issued, want 1h", age)
+	}
+
+	if strings.Contains(header.Get("Cookie"), sessionCookie) {
+		t.Errorf("gateway cookies were sent upstream: %q", header.Get("Cookie"))
+	}
+}
+
+func TestCallbackRejectsForgedState(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	res := f.navigate(t, "/reports")
+	res.Body.Close()
+
+	callback := f.browser.Authorize(res.Header.Get("Location"))
+	u, _ := url.Parse(callback)
+	query := u.Query()
+	query.Set("state", "forged")
+	u.RawQuery = query.Encode()
+
+	res, err := f.browser.Get(u.String())
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest || f.session(t) != nil {
+		t.Errorf("forged state: status %d, session %+v", res.StatusCode, f.session(t))
+	}
+}
+
+func TestUnauthenticatedAPICall(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	res, err := http.Get(f.server.URL + "/reports")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(res.Header.Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("status = %d, WWW-Authenticate = %q", res.StatusCode, res.Header.Get("WWW-Authenticate"))
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	call := func(audience string) *http.Response {
+		accessToken := f.issuer.AccessToken(t, jwtGen.Claim{Audience: audience, Subject: "user-2", Scopes: []string{"report.read"}}, time.Minute)
+		req, _ := http.NewRequest(http.MethodGet, f.server.URL+"/reports", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set(HeaderUser, "admin")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+
+		return res
+	}
+
+	header := upstreamHeaders(t, call(f.clientID))
+	if header.Get(HeaderUser) != "user-2" || len(header.Get(HeaderCSRFToken)) > 0 {
+		t.Errorf("upstream headers = %v", header)
+	}
+
+	res := call("another-client")
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("token of another client: status %d", res.StatusCode)
+	}
+}
+
+func TestRefreshExpiredAccessToken(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	f.login(t, "/reports")
+
+	expired := f.session(t)
+	expired.TokenExpiresAt = time.Now().Add(-time.Minute)
+	f.setSession(t, expired)
+
+	header := upstreamHeaders(t, f.navigate(t, "/reports"))
+	if header.Get(HeaderAccessToken) == expired.AccessToken {
+		t.Error("expired access token was forwarded")
+	}
+
+	refreshed := f.session(t)
+	if refreshed.RefreshToken == expired.RefreshToken || refreshed.AccessToken != header.Get(HeaderAccessToken) {
+		t.Errorf("session was not refreshed: %+v", refreshed)
+	}
+
+	if !refreshed.IssuedAt.Equal(expired.IssuedAt) || !refreshed.ExpiresAt.Equal(expired.ExpiresAt) || refreshed.CSRFToken != expired.CSRFToken {
+		t.Errorf("refresh changed the session lifetime or csrf token: %+v", refreshed)
+	}
+
+	// refresh token of the old cookie has been rotated by the issuer
+	_, err := f.gateway.oauth2.Refresh(context.Background(), expired.RefreshToken)
+	if !client.IsCode(err, client.ErrorInvalidGrant) {
+		t.Errorf("err = %v, want invalid_grant for rotated refresh token", err)
+	}
+}
+
+func TestExpiredSessionIsRejected(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	f.login(t, "/reports")
+	sess := f.session(t)
+
+	tests := []struct {
+		name   string
+		change func(sess *session)
+	}{
+		{"expired", func(sess *session) { sess.ExpiresAt = time.Now().Add(-time.Second) }},
+		{"older than session age", func(sess *session) {
+			sess.IssuedAt = time.Now().Add(-2 * time.Hour)
+			sess.ExpiresAt = time.Now().Add(time.Hour)
+		}},
+		{"no issued-at", func(sess *session) { sess.IssuedAt = time.Time{} }},
+	}
+
+	for _, test := range tests {
+		changed := *sess
+		test.change(&changed)
+		f.setSession(t, &changed)
+
+		res := f.navigate(t, "/reports")
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusFound || !strings.HasPrefix(res.Header.Get("Location"), f.issuer.URL) {
+			t.Errorf("%s: status %d, location %q, want redirect to login", test.name, res.StatusCode, res.Header.Get("Location"))
+		}
+
+		if f.session(t) != nil {
+			t.Errorf("%s: session cookie was not cleared", test.name)
+		}
+	}
+}
+
+func TestSignOut(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	defer f.close()
+
+	header := f.login(t, "/reports")
+	sess := f.session(t)
+
+	res := f.navigate(t, SignOutPath)
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET sign out: status %d", res.StatusCode)
+	}
+
+	res, err := f.browser.PostForm(f.server.URL+SignOutPath, url.Values{CSRFTokenField: {"forged"}})
+	if err != nil {
+		t.Fatalf("sign out: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusForbidden || f.session(t) == nil {
+		t.Errorf("sign out with forged csrf token: status %d", res.StatusCode)
+	}
+
+	res, err = f.browser.PostForm(f.server.URL+SignOutPath, url.Values{CSRFTokenField: {header.Get(HeaderCSRFToken)}})
+	if err != nil {
+		t.Fatalf("sign out: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusSeeOther || f.session(t) != nil {
+		t.Errorf("sign out: status %d, session %+v", res.StatusCode, f.session(t))
+	}
+
+	_, err = f.gateway.oauth2.Refresh(context.Background(), sess.RefreshToken)
+	if !client.IsCode(err, client.ErrorInvalidGrant) {
+		t.Errorf("err = %v, want invalid_grant for refresh token of signed out session", err)
+	}
+}
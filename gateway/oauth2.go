@@ -0,0 +1,215 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/musobarlab/oauth2-go/client"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+)
+
+// startLogin redirect browser to authorization endpoint, state, nonce and PKCE verifier wait in the flow cookie
+func (g *Gateway) startLogin(res http.ResponseWriter, req *http.Request) {
+	authRequest, err := g.oauth2.AuthCodeURL(nil)
+	if err != nil {
+		http.Error(res, "error generate login request", http.StatusInternalServerError)
+		return
+	}
+
+	flow := &flowState{
+		State:        authRequest.State,
+		Nonce:        authRequest.Nonce,
+		CodeVerifier: authRequest.CodeVerifier,
+		RedirectTo:   req.URL.RequestURI(),
+	}
+
+	if err := g.writeCookie(res, flowCookie, flow, flowAge); err != nil {
+		http.Error(res, "error save login state", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(res, req, authRequest.URL, http.StatusFound)
+}
+
+// callback exchange authorization code for tokens and start the session
+func (g *Gateway) callback(res http.ResponseWriter, req *http.Request) {
+	var flow flowState
+	if err := g.readCookie(req, flowCookie, &flow); err != nil {
+		http.Error(res, "login state not found, please try again", http.StatusBadRequest)
+		return
+	}
+
+	g.clearCookie(res, flowCookie)
+
+	token, err := g.oauth2.HandleCallback(req.Context(), req, &client.AuthRequest{State: flow.State, Nonce: flow.Nonce, CodeVerifier: flow.CodeVerifier})
+	if err != nil {
+		if err == client.ErrStateMismatch {
+			http.Error(res, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		// error sent to the redirect uri has no status, the user did not allow the login
+		if oauth2Err, ok := err.(*client.Error); ok && oauth2Err.StatusCode == 0 {
+			http.Error(res, "login failed: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		http.Error(res, "login failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	sess, err := g.newSession(req.Context(), token)
+	if err != nil {
+		http.Error(res, "login failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	sess.CSRFToken, err = appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		http.Error(res, "error generate session", http.StatusInternalServerError)
+		return
+	}
+
+	sess.IssuedAt = time.Now()
+	sess.ExpiresAt = sess.IssuedAt.Add(g.config.SessionAge)
+
+	if err := g.saveSession(res, sess); err != nil {
+		http.Error(res, "error save session", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(res, req, localRedirect(flow.RedirectTo), http.StatusFound)
+}
+
+// signOut clear the session, refresh token is revoked so it can not be used again,
+// failure is ignored since the cookie is cleared anyway.
+// a page of another site must not sign the user out, a valid session is ended only with its csrf token
+func (g *Gateway) signOut(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sess, err := g.loadSession(req); err == nil {
+		if subtle.ConstantTimeCompare([]byte(req.PostFormValue(CSRFTokenField)), []byte(sess.CSRFToken)) != 1 {
+			http.Error(res, "invalid csrf token", http.StatusForbidden)
+			return
+		}
+
+		if len(sess.RefreshToken) > 0 {
+			g.oauth2.Revoke(req.Context(), sess.RefreshToken, "refresh_token")
+		}
+	}
+
+	g.clearCookie(res, sessionCookie)
+	http.Redirect(res, req, "/", http.StatusSeeOther)
+}
+
+// refreshSession get new access token with refresh token of sess,
+// refreshed session keeps csrf token, issued-at and expiry of sess
+func (g *Gateway) refreshSession(ctx context.Context, sess *session) (*session, error) {
+	if len(sess.RefreshToken) <= 0 {
+		return nil, errors.New("session has no refresh token")
+	}
+
+	token, err := g.oauth2.Refresh(ctx, sess.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed, err := g.newSession(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed.CSRFToken = sess.CSRFToken
+	refreshed.IssuedAt = sess.IssuedAt
+	refreshed.ExpiresAt = sess.ExpiresAt
+	return refreshed, nil
+}
+
+// newSession verify access token and keep it with the user in session
+func (g *Gateway) newSession(ctx context.Context, token *client.Token) (*session, error) {
+	claims, err := g.verifier.Verify(ctx, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+		TokenExpiresAt: token.Expiry,
+		Subject:        claims.Subject,
+		Email:          claims.Email,
+	}, nil
+}
+
+// localRedirect accept only path on the gateway itself, so the flow cookie can not send the user elsewhere
+func localRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+
+	return target
+}
+
+// refreshCall data structure
+type refreshCall struct {
+	done     chan struct{}
+	sess     *session
+	err      error
+	finished time.Time
+}
+
+// refresher data structure
+// refresh token rotates on every use and reuse revokes the whole family, so parallel requests
+// carrying the same expired session share one refresh, its result is kept for requests arriving shortly after
+type refresher struct {
+	sync.Mutex
+	calls map[string]*refreshCall
+}
+
+// refreshResultAge how long a refresh result is handed to requests still carrying the old cookie
+const refreshResultAge = 30 * time.Second
+
+// newRefresher function
+func newRefresher() *refresher {
+	return &refresher{calls: make(map[string]*refreshCall)}
+}
+
+// refresh run fn once per refresh token
+func (r *refresher) refresh(sess *session, fn func(sess *session) (*session, error)) (*session, error) {
+	r.Lock()
+	now := time.Now()
+	for k, v := range r.calls {
+		if !v.finished.IsZero() && now.Sub(v.finished) > refreshResultAge {
+			delete(r.calls, k)
+		}
+	}
+
+	call, ok := r.calls[sess.RefreshToken]
+	if ok {
+		r.Unlock()
+		<-call.done
+		return call.sess, call.err
+	}
+
+	call = &refreshCall{done: make(chan struct{})}
+	r.calls[sess.RefreshToken] = call
+	r.Unlock()
+
+	call.sess, call.err = fn(sess)
+
+	r.Lock()
+	call.finished = time.Now()
+	r.Unlock()
+	close(call.done)
+
+	return call.sess, call.err
+}
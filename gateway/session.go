@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	sessionCookie = "_oauth2_gateway"
+	flowCookie    = "_oauth2_gateway_flow"
+
+	// flowAge how long the user has to finish login
+	flowAge = 10 * time.Minute
+
+	// expiryMargin refresh access token a bit before it expires, so it does not expire on the way to the upstream
+	expiryMargin = 10 * time.Second
+)
+
+// errSessionExpired returned by loadSession for session past its expiry, the user has to login again
+var errSessionExpired = errors.New("session expired")
+
+// session data structure
+// kept encrypted in the session cookie, IssuedAt and ExpiresAt are fixed at login and kept by refresh,
+// so the session ends after SessionAge however long its refresh token lives
+type session struct {
+	AccessToken    string    `json:"at"`
+	RefreshToken   string    `json:"rt,omitempty"`
+	TokenExpiresAt time.Time `json:"at_exp"`
+	Subject        string    `json:"sub"`
+	Email          string    `json:"email,omitempty"`
+	CSRFToken      string    `json:"csrf"`
+	IssuedAt       time.Time `json:"iat"`
+	ExpiresAt      time.Time `json:"exp"`
+}
+
+// isTokenExpired function
+func (s *session) isTokenExpired() bool {
+	return time.Now().Add(expiryMargin).After(s.TokenExpiresAt)
+}
+
+// flowState data structure
+// authorization request in progress, kept encrypted in the flow cookie until the callback
+type flowState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"verifier"`
+	RedirectTo   string `json:"redirect_to"`
+}
+
+// loadSession function
+// cookie lifetime is up to the browser, expiry sealed in the session is checked here,
+// SessionAge applies to sessions issued before it was shortened too
+func (g *Gateway) loadSession(req *http.Request) (*session, error) {
+	var sess session
+	if err := g.readCookie(req, sessionCookie, &sess); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if sess.IssuedAt.IsZero() || !now.Before(sess.ExpiresAt) || now.Sub(sess.IssuedAt) > g.config.SessionAge {
+		return nil, errSessionExpired
+	}
+
+	return &sess, nil
+}
+
+// saveSession function
+// cookie expires with the session
+func (g *Gateway) saveSession(res http.ResponseWriter, sess *session) error {
+	return g.writeCookie(res, sessionCookie, sess, time.Until(sess.ExpiresAt))
+}
+
+// readCookie decrypt cookie into v
+func (g *Gateway) readCookie(req *http.Request, name string, v interface{}) error {
+	c, err := req.Cookie(name)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := g.cipher.Decrypt(c.Value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(plaintext), v)
+}
+
+// writeCookie encrypt v into cookie
+func (g *Gateway) writeCookie(res http.ResponseWriter, name string, v interface{}, age time.Duration) error {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	value, err := g.cipher.Encrypt(string(plaintext))
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(res, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(age / time.Second),
+		Expires:  time.Now().Add(age),
+		Secure:   g.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// clearCookie function
+func (g *Gateway) clearCookie(res http.ResponseWriter, name string) {
+	http.SetCookie(res, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   g.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,91 @@
+package oauth2test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+var challengePattern = regexp.MustCompile(`name="challenge" value="([^"]+)"`)
+
+// Browser struct
+// user agent keeping cookies, redirects are returned to the test instead of followed
+type Browser struct {
+	*http.Client
+
+	t      testing.TB
+	server *Server
+}
+
+// NewBrowser function
+func (s *Server) NewBrowser(t testing.TB) *Browser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("new cookie jar: %v", err)
+	}
+
+	return &Browser{
+		Client: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		t:      t,
+		server: s,
+	}
+}
+
+// Login function
+// login to the server, the session cookie is kept by the browser
+func (b *Browser) Login(email, password string) {
+	res, err := b.PostForm(b.server.URL+"/post_login", url.Values{"email": {email}, "password": {password}})
+	if err != nil {
+		b.t.Fatalf("login: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b.t.Fatalf("login: status %d", res.StatusCode)
+	}
+}
+
+// Authorize function
+// open authorization URL as the logged in user, allow the consent page when it is shown,
+// return the redirect uri the server sends the browser back to
+func (b *Browser) Authorize(authURL string) string {
+	res, err := b.Get(authURL)
+	if err != nil {
+		b.t.Fatalf("authorize: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		b.t.Fatalf("read authorize response: %v", err)
+	}
+
+	if res.StatusCode == http.StatusFound {
+		return res.Header.Get("Location")
+	}
+
+	match := challengePattern.FindSubmatch(body)
+	if match == nil {
+		b.t.Fatalf("authorize: no consent page, status %d: %s", res.StatusCode, body)
+	}
+
+	res, err = b.PostForm(b.server.URL+"/post_authorize_user", url.Values{"challenge": {string(match[1])}, "decision": {"allow"}})
+	if err != nil {
+		b.t.Fatalf("allow consent: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound {
+		b.t.Fatalf("allow consent: status %d", res.StatusCode)
+	}
+
+	return res.Header.Get("Location")
+}
@@ -0,0 +1,255 @@
+// Package oauth2test run the authorization server on httptest for tests of packages using it,
+// handlers are wired to in-memory repositories the same way main.go does
+package oauth2test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	appDelivery "github.com/musobarlab/oauth2-go/core/application/delivery"
+	appModel "github.com/musobarlab/oauth2-go/core/application/model"
+	appRepo "github.com/musobarlab/oauth2-go/core/application/repository"
+	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	authCodeModel "github.com/musobarlab/oauth2-go/core/authcode/model"
+	authCodeRepo "github.com/musobarlab/oauth2-go/core/authcode/repository"
+	consentDelivery "github.com/musobarlab/oauth2-go/core/consent/delivery"
+	consentModel "github.com/musobarlab/oauth2-go/core/consent/model"
+	consentRepo "github.com/musobarlab/oauth2-go/core/consent/repository"
+	discoveryDelivery "github.com/musobarlab/oauth2-go/core/discovery/delivery"
+	"github.com/musobarlab/oauth2-go/core/key"
+	keyDelivery "github.com/musobarlab/oauth2-go/core/key/delivery"
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
+	userDelivery "github.com/musobarlab/oauth2-go/core/user/delivery"
+	userModel "github.com/musobarlab/oauth2-go/core/user/model"
+	userRepo "github.com/musobarlab/oauth2-go/core/user/repository"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+const (
+	// InitialAccessToken required by client registration of the server
+	InitialAccessToken = "initial-access-token"
+
+	// AccessTokenAge of access token issued by the server
+	AccessTokenAge = 5 * time.Minute
+
+	// RefreshTokenAge of refresh token issued by the server
+	RefreshTokenAge = 720 * time.Hour
+)
+
+// Scopes registered in addition to the OpenID Connect scopes
+var Scopes = []*scopeModel.Scope{
+	{Name: "report.read", Description: "Read your reports"},
+	{Name: "report.write", Description: "Change your reports"},
+}
+
+// chdir once, handlers parse templates from ./static of the repository root
+var chdirOnce sync.Once
+
+// Server struct
+// authorization server with its signing keys, requests are counted by path
+type Server struct {
+	*httptest.Server
+
+	Keys     *key.Manager
+	UserRepo userRepo.Repository
+	Denylist tokenRepo.Denylist
+
+	mutex    sync.Mutex
+	requests map[string]int
+	disabled map[string]bool
+}
+
+// NewServer function
+// start the server, its issuer is the httptest URL
+func NewServer(t testing.TB) *Server {
+	chdirOnce.Do(func() {
+		_, file, _, _ := runtime.Caller(0)
+		if err := os.Chdir(filepath.Join(filepath.Dir(file), "..", "..")); err != nil {
+			t.Fatalf("chdir to repository root: %v", err)
+		}
+	})
+
+	privateKey, err := key.GenerateKey("ES256")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	keyManager, err := key.NewManager(privateKey, time.Hour)
+	if err != nil {
+		t.Fatalf("new key manager: %v", err)
+	}
+
+	cookieSecret, err := appSecurity.GenerateRandomToken(32)
+	if err != nil {
+		t.Fatalf("generate cookie secret: %v", err)
+	}
+
+	cookieCipher, err := appSecurity.NewAESGCM(cookieSecret)
+	if err != nil {
+		t.Fatalf("new cookie cipher: %v", err)
+	}
+
+	appRepository := appRepo.NewInMemory(make(map[string]*appModel.Application))
+	userRepository := userRepo.NewInMemory(make(map[string]*userModel.User))
+	refreshTokenRepository := tokenRepo.NewInMemory(make(map[string]*tokenModel.RefreshToken))
+	authCodeRepository := authCodeRepo.NewInMemory(make(map[string]*authCodeModel.AuthorizationCode))
+	denylist := tokenRepo.NewDenylistInMemory(make(map[string]time.Time))
+	clientAssertionReplay := tokenRepo.NewDenylistInMemory(make(map[string]time.Time))
+	consentRepository := consentRepo.NewInMemory(make(map[string]*consentModel.Consent))
+	consentRequestRepository := consentRepo.NewRequestInMemory(make(map[string]*consentModel.Request))
+	scopeRepository := scopeRepo.NewInMemory(make(map[string]*scopeModel.Scope))
+
+	for _, scope := range append(scopeModel.StandardScopes(), Scopes...) {
+		scopeRepository.Save(scope)
+	}
+
+	s := &Server{Keys: keyManager, UserRepo: userRepository, Denylist: denylist, requests: make(map[string]int), disabled: make(map[string]bool)}
+
+	mux := http.NewServeMux()
+	s.Server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		s.requests[req.URL.Path]++
+		disabled := s.disabled[req.URL.Path]
+		s.mutex.Unlock()
+
+		if disabled {
+			http.NotFound(res, req)
+			return
+		}
+
+		mux.ServeHTTP(res, req)
+	}))
+
+	issuer := s.URL
+
+	accessTokenGenerator := jwtGen.NewJwtGenerator(keyManager, AccessTokenAge)
+	idTokenGenerator := jwtGen.NewIDTokenGenerator(keyManager, time.Hour)
+
+	appHandler := &appDelivery.Handler{AppRepo: appRepository, UserRepo: userRepository, AccessTokenGenerator: accessTokenGenerator, IDTokenGenerator: idTokenGenerator, RefreshTokenRepo: refreshTokenRepository, RefreshTokenAge: RefreshTokenAge, Denylist: denylist, AuthorizationCodeRepo: authCodeRepository, AuthorizationCodeAge: time.Minute, Keys: keyManager, SigningAlgorithms: keyManager.Algorithms(), Issuer: issuer, TokenEndpoint: issuer + "/api/oauth2/token", ClientAssertionReplay: clientAssertionReplay, RegistrationEndpoint: issuer + "/register", InitialAccessToken: InitialAccessToken, ConsentRepo: consentRepository, ConsentRequestRepo: consentRequestRepository, ConsentRequestAge: 10 * time.Minute, ScopeRepo: scopeRepository, CookieCipher: cookieCipher}
+	userHandler := &userDelivery.Handler{UserRepo: userRepository, AccessTokenGenerator: accessTokenGenerator, Issuer: issuer, Keys: keyManager, Denylist: denylist, ScopeRepo: scopeRepository, CookieCipher: cookieCipher}
+	consentHandler := &consentDelivery.Handler{ConsentRepo: consentRepository, AppRepo: appRepository, UserRepo: userRepository, RefreshTokenRepo: refreshTokenRepository, Denylist: denylist, CookieCipher: cookieCipher}
+	keyHandler := &keyDelivery.Handler{KeyManager: keyManager}
+
+	discoveryHandler := &discoveryDelivery.Handler{
+		Mux:                           mux,
+		Issuer:                        issuer,
+		GrantTypes:                    appHandler.GrantTypes(),
+		ResponseTypes:                 appHandler.ResponseTypes(),
+		Scopes:                        appHandler.Scopes(),
+		TokenEndpointAuthMethods:      appHandler.TokenEndpointAuthMethods(),
+		EndpointAuthMethods:           appHandler.EndpointAuthMethods(),
+		EndpointAuthSigningAlgorithms: appHandler.EndpointAuthSigningAlgorithms(),
+		SigningAlgorithms:             keyManager.Algorithms(),
+		CodeChallengeMethods:          appHandler.CodeChallengeMethods(),
+		Claims:                        appHandler.Claims(),
+	}
+
+	discoveryHandler.HandleFunc(discoveryDelivery.AuthorizationEndpoint, "/get_authorize_user", appHandler.GetAuthorizeUser())
+	mux.HandleFunc("/post_authorize_user", appHandler.PostAuthorizeUser())
+	mux.HandleFunc("/consents", consentHandler.ListConsentHandler())
+	mux.HandleFunc("/consents/revoke", consentHandler.RevokeConsentHandler())
+	mux.HandleFunc("/post_login", userHandler.PostLogin())
+
+	discoveryHandler.HandleFunc(discoveryDelivery.RegistrationEndpoint, "/register", appHandler.RegisterHandler())
+	mux.HandleFunc("/register/", appHandler.ClientConfigurationHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.TokenEndpoint, "/api/oauth2/token", appHandler.OAuth2Handler())
+	discoveryHandler.HandleFunc(discoveryDelivery.IntrospectionEndpoint, "/api/oauth2/introspect", appHandler.IntrospectHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.RevocationEndpoint, "/api/oauth2/revoke", appHandler.RevokeHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.UserInfoEndpoint, "/userinfo", userHandler.UserInfo())
+	discoveryHandler.HandleFunc(discoveryDelivery.JWKSURI, "/.well-known/jwks.json", keyHandler.JWKSHandler())
+
+	mux.HandleFunc("/.well-known/oauth-authorization-server", discoveryHandler.AuthorizationServerHandler())
+	mux.HandleFunc("/.well-known/openid-configuration", discoveryHandler.OpenIDConfigurationHandler())
+
+	return s
+}
+
+// Requests function
+// number of requests the server has received for path
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[path]
+}
+
+// Disable function
+// answer not found on path, as a server without the endpoint would
+func (s *Server) Disable(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.disabled[path] = true
+}
+
+// RegisterClient function
+// register client through dynamic client registration
+func (s *Server) RegisterClient(t testing.TB, metadata appModel.ClientMetadata) *appModel.ClientInformation {
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatalf("marshal client metadata: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/register", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new register request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+InitialAccessToken)
+
+	res, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("register client: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("register client: status %d", res.StatusCode)
+	}
+
+	var information appModel.ClientInformation
+	if err := json.NewDecoder(res.Body).Decode(&information); err != nil {
+		t.Fatalf("decode client information: %v", err)
+	}
+
+	return &information
+}
+
+// CreateUser function
+// save user who can login with email and password
+func (s *Server) CreateUser(t testing.TB, id, email, password string) *userModel.User {
+	user := &userModel.User{ID: id, Name: "User " + id, Email: email, Password: password}
+	if output := s.UserRepo.Save(user); output.Error != nil {
+		t.Fatalf("save user: %v", output.Error)
+	}
+
+	return user
+}
+
+// AccessToken function
+// sign access token with the server keys, issuer is the server unless claim has one,
+// tokenAge may be negative for an expired token
+func (s *Server) AccessToken(t testing.TB, claim jwtGen.Claim, tokenAge time.Duration) string {
+	if len(claim.Issuer) <= 0 {
+		claim.Issuer = s.URL
+	}
+
+	result := <-jwtGen.NewJwtGenerator(s.Keys, tokenAge).GenerateAccessToken(claim)
+	if result.Error != nil {
+		t.Fatalf("generate access token: %v", result.Error)
+	}
+
+	return result.AccessToken.AccessToken
+}
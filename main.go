@@ -1,41 +1,132 @@
 package main
 
 import (
+	"context"
+	"crypto"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	rsaConf "github.com/musobarlab/oauth2-go/config/rsa"
+	keyConf "github.com/musobarlab/oauth2-go/config/key"
+	scopeConf "github.com/musobarlab/oauth2-go/config/scope"
 	appDelivery "github.com/musobarlab/oauth2-go/core/application/delivery"
 	appModel "github.com/musobarlab/oauth2-go/core/application/model"
 	appRepo "github.com/musobarlab/oauth2-go/core/application/repository"
 	appSecurity "github.com/musobarlab/oauth2-go/core/application/security"
+	authCodeModel "github.com/musobarlab/oauth2-go/core/authcode/model"
+	authCodeRepo "github.com/musobarlab/oauth2-go/core/authcode/repository"
+	consentDelivery "github.com/musobarlab/oauth2-go/core/consent/delivery"
+	consentModel "github.com/musobarlab/oauth2-go/core/consent/model"
+	consentRepo "github.com/musobarlab/oauth2-go/core/consent/repository"
+	discoveryDelivery "github.com/musobarlab/oauth2-go/core/discovery/delivery"
+	"github.com/musobarlab/oauth2-go/core/key"
+	keyDelivery "github.com/musobarlab/oauth2-go/core/key/delivery"
+	scopeModel "github.com/musobarlab/oauth2-go/core/scope/model"
+	scopeRepo "github.com/musobarlab/oauth2-go/core/scope/repository"
+	tokenModel "github.com/musobarlab/oauth2-go/core/token/model"
+	tokenRepo "github.com/musobarlab/oauth2-go/core/token/repository"
 
 	userDelivery "github.com/musobarlab/oauth2-go/core/user/delivery"
 	userModel "github.com/musobarlab/oauth2-go/core/user/model"
 	userRepo "github.com/musobarlab/oauth2-go/core/user/repository"
 	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
 
+	"github.com/musobarlab/oauth2-go/gateway"
 	"github.com/musobarlab/oauth2-go/middleware"
 )
 
 func main() {
 	var (
-		port int64
+		port          int64
+		codeStorePath string
+		issuer        string
+		keyPath       string
+		keyDir        string
+		keyRotation   time.Duration
+		algorithm     string
+		algorithms    string
+		initialToken  string
+		scopePath     string
+		gatewayMode   bool
+		gatewayConfig gateway.Config
+		gatewayScopes string
+		gatewayAud    string
 	)
 
 	flag.Int64Var(&port, "p", 9000, "port to listen")
+	flag.StringVar(&issuer, "issuer", "", "issuer identifier (https URL) of issued tokens, default http://localhost:<port>")
+	flag.StringVar(&keyPath, "key", "config/rsa/app.rsa", "PEM file of the initial active signing key (RSA, EC P-256 or Ed25519)")
+	flag.StringVar(&algorithm, "alg", "", "default signing algorithm (RS256, PS256, ES256 or EdDSA), derived from -key if empty")
+	flag.StringVar(&algorithms, "algs", "", "comma separated additional signing algorithms clients may choose for id_token, ALG=path loads its key from PEM file, e.g. ES256=config/ec.pem,EdDSA")
+	flag.StringVar(&keyDir, "key-dir", "", "directory keeping signing keys and their rotation state across restarts, seeded from -key when empty; keys are in memory only if not set")
+	flag.DurationVar(&keyRotation, "key-rotation", 0, "rotate signing keys every interval, 0 disables scheduled rotation (SIGHUP rotates on demand)")
+	flag.StringVar(&initialToken, "initial-access-token", "", "bearer token required by dynamic client registration, if empty anyone may register clients limited to OpenID Connect and default scopes")
+	flag.StringVar(&scopePath, "scopes", "", "json file of scopes clients may request in addition to the OpenID Connect scopes")
+	flag.StringVar(&codeStorePath, "code-store", "", "json file for persisting authorization codes, in memory if empty")
+
+	flag.BoolVar(&gatewayMode, "gateway", false, "run as login gateway in front of -upstream, using the server at -issuer")
+	flag.StringVar(&gatewayConfig.Upstream, "upstream", "", "gateway mode: url of the service requests are forwarded to")
+	flag.StringVar(&gatewayConfig.ClientID, "client-id", "", "gateway mode: client id registered at the issuer")
+	flag.StringVar(&gatewayConfig.ClientSecret, "client-secret", "", "gateway mode: client secret registered at the issuer")
+	flag.StringVar(&gatewayConfig.RedirectURL, "redirect-url", "", "gateway mode: registered redirect uri served by the gateway, e.g. http://localhost:4180/oauth2/callback")
+	flag.StringVar(&gatewayConfig.CookieSecret, "cookie-secret", "", "secret encrypting cookies (login session, gateway session), at least 32 random characters, random per start in server mode if empty")
+	flag.StringVar(&gatewayScopes, "gateway-scopes", "openid profile email", "gateway mode: space separated scopes requested at login")
+	flag.StringVar(&gatewayAud, "gateway-audience", "", "gateway mode: space separated client ids whose bearer tokens are accepted besides -client-id")
+	flag.DurationVar(&gatewayConfig.SessionAge, "session-age", 24*time.Hour, "gateway mode: lifetime of the login session, refreshing its access token does not extend it")
 
 	flag.Parse()
 
+	if gatewayMode {
+		gatewayConfig.Issuer = issuer
+		gatewayConfig.Scopes = strings.Fields(gatewayScopes)
+		gatewayConfig.Audience = strings.Fields(gatewayAud)
+		runGateway(port, gatewayConfig)
+		return
+	}
+
+	if len(issuer) <= 0 {
+		issuer = fmt.Sprintf("http://localhost:%d", port)
+	}
+
 	appDB := make(map[string]*appModel.Application)
 	userDB := make(map[string]*userModel.User)
+	refreshTokenDB := make(map[string]*tokenModel.RefreshToken)
+	authCodeDB := make(map[string]*authCodeModel.AuthorizationCode)
+	denylistDB := make(map[string]time.Time)
+	clientAssertionDB := make(map[string]time.Time)
+	consentDB := make(map[string]*consentModel.Consent)
+	consentRequestDB := make(map[string]*consentModel.Request)
+	scopeDB := make(map[string]*scopeModel.Scope)
 
 	appRepository := appRepo.NewInMemory(appDB)
 	userRepository := userRepo.NewInMemory(userDB)
+	refreshTokenRepository := tokenRepo.NewInMemory(refreshTokenDB)
+	denylist := tokenRepo.NewDenylistInMemory(denylistDB)
+	clientAssertionReplay := tokenRepo.NewDenylistInMemory(clientAssertionDB)
+	consentRepository := consentRepo.NewInMemory(consentDB)
+	consentRequestRepository := consentRepo.NewRequestInMemory(consentRequestDB)
+	scopeRepository := scopeRepo.NewInMemory(scopeDB)
+
+	scopes := scopeModel.StandardScopes()
+	if len(scopePath) > 0 {
+		customScopes, err := scopeConf.InitScopesFromFile(scopePath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		scopes = append(scopes, customScopes...)
+	}
+
+	for _, scope := range scopes {
+		scopeRepository.Save(scope)
+	}
 
 	accessTokenAge, err := time.ParseDuration("5m")
 	if err != nil {
@@ -43,41 +134,212 @@ func main() {
 		os.Exit(1)
 	}
 
-	privateKey, err := rsaConf.InitPrivateKey()
+	refreshTokenAge, err := time.ParseDuration("720h")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	idTokenAge, err := time.ParseDuration("1h")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	authCodeAge, err := time.ParseDuration("1m")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	consentRequestAge, err := time.ParseDuration("10m")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var authCodeRepository authCodeRepo.Repository = authCodeRepo.NewInMemory(authCodeDB)
+	if len(codeStorePath) > 0 {
+		authCodeRepository, err = authCodeRepo.NewFile(codeStorePath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	privateKey, err := keyConf.InitPrivateKeyFromFile(keyPath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// retiring key must keep verifying until the longest lived token it signed has expired
+	retireAfter := accessTokenAge
+	if idTokenAge > retireAfter {
+		retireAfter = idTokenAge
+	}
+
+	if len(algorithm) <= 0 {
+		algorithm, err = key.DefaultAlgorithm(privateKey)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	keyManager, err := key.NewManagerWithAlgorithm(privateKey, algorithm, retireAfter)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	publicKey, err := rsaConf.InitPublicKey()
+	if len(keyDir) > 0 {
+		keyStore, err := key.NewDirStore(keyDir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := keyManager.UseStore(keyStore); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	// ALG=path loads the key of alg from PEM file, key of bare ALG is generated and lives in memory only unless -key-dir is set
+	for _, alg := range strings.Split(algorithms, ",") {
+		alg = strings.TrimSpace(alg)
+		if len(alg) <= 0 {
+			continue
+		}
+
+		var err error
+		if i := strings.Index(alg, "="); i >= 0 {
+			var algKey crypto.Signer
+			algKey, err = keyConf.InitPrivateKeyFromFile(alg[i+1:])
+			if err == nil {
+				err = keyManager.EnableAlgorithmWithKey(algKey, alg[:i])
+			}
+		} else {
+			err = keyManager.EnableAlgorithm(alg)
+		}
+
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if keyRotation > 0 {
+		stopRotation := keyManager.StartRotation(keyRotation)
+		defer stopRotation()
+	}
+
+	go func() {
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		for range sighup {
+			if err := keyManager.Rotate(); err != nil {
+				log.Println("rotate signing key:", err)
+				continue
+			}
+			log.Println("signing key rotated")
+		}
+	}()
+
+	cookieSecret := gatewayConfig.CookieSecret
+	if len(cookieSecret) <= 0 {
+		cookieSecret, err = appSecurity.GenerateRandomToken(32)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	cookieCipher, err := appSecurity.NewAESGCM(cookieSecret)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	security := appSecurity.NewAES("orakepriwekepriw")
+	accessTokenGenerator := jwtGen.NewJwtGenerator(keyManager, accessTokenAge)
+	idTokenGenerator := jwtGen.NewIDTokenGenerator(keyManager, idTokenAge)
+
+	appHandler := &appDelivery.Handler{AppRepo: appRepository, UserRepo: userRepository, AccessTokenGenerator: accessTokenGenerator, IDTokenGenerator: idTokenGenerator, RefreshTokenRepo: refreshTokenRepository, RefreshTokenAge: refreshTokenAge, Denylist: denylist, AuthorizationCodeRepo: authCodeRepository, AuthorizationCodeAge: authCodeAge, Keys: keyManager, SigningAlgorithms: keyManager.Algorithms(), Issuer: issuer, TokenEndpoint: issuer + "/api/oauth2/token", ClientAssertionReplay: clientAssertionReplay, RegistrationEndpoint: issuer + "/register", InitialAccessToken: initialToken, ConsentRepo: consentRepository, ConsentRequestRepo: consentRequestRepository, ConsentRequestAge: consentRequestAge, ScopeRepo: scopeRepository, CookieCipher: cookieCipher}
+	userHandler := &userDelivery.Handler{UserRepo: userRepository, AccessTokenGenerator: accessTokenGenerator, Issuer: issuer, Keys: keyManager, Denylist: denylist, ScopeRepo: scopeRepository, CookieCipher: cookieCipher}
 
-	accessTokenGenerator := jwtGen.NewJwtGenerator(privateKey, accessTokenAge)
+	consentHandler := &consentDelivery.Handler{ConsentRepo: consentRepository, AppRepo: appRepository, UserRepo: userRepository, RefreshTokenRepo: refreshTokenRepository, Denylist: denylist, CookieCipher: cookieCipher}
 
-	appHandler := &appDelivery.Handler{AppRepo: appRepository, UserRepo: userRepository, Security: security, AccessTokenGenerator: accessTokenGenerator}
-	userHandler := &userDelivery.Handler{UserRepo: userRepository, AccessTokenGenerator: accessTokenGenerator}
+	keyHandler := &keyDelivery.Handler{KeyManager: keyManager}
+
+	mux := http.NewServeMux()
+
+	discoveryHandler := &discoveryDelivery.Handler{
+		Mux:                           mux,
+		Issuer:                        issuer,
+		GrantTypes:                    appHandler.GrantTypes(),
+		ResponseTypes:                 appHandler.ResponseTypes(),
+		Scopes:                        appHandler.Scopes(),
+		TokenEndpointAuthMethods:      appHandler.TokenEndpointAuthMethods(),
+		EndpointAuthMethods:           appHandler.EndpointAuthMethods(),
+		EndpointAuthSigningAlgorithms: appHandler.EndpointAuthSigningAlgorithms(),
+		SigningAlgorithms:             keyManager.Algorithms(),
+		CodeChallengeMethods:          appHandler.CodeChallengeMethods(),
+		Claims:                        appHandler.Claims(),
+	}
 
 	//fs := http.FileServer(http.Dir("static"))
-	http.HandleFunc("/", appHandler.IndexHandler())
-	http.HandleFunc("/get_register", appHandler.GetRegisterHandler())
-	http.HandleFunc("/post_register", appHandler.PostRegisterHandler())
-	http.HandleFunc("/get_authorize_user", appHandler.GetAuthorizeUser())
-	http.HandleFunc("/list_app", appHandler.ListAppHandler())
-	http.HandleFunc("/get_login", userHandler.GetLogin())
-	http.HandleFunc("/post_login", userHandler.PostLogin())
-	http.HandleFunc("/about", appHandler.AboutHandler())
+	mux.HandleFunc("/", appHandler.IndexHandler())
+	mux.HandleFunc("/get_register", appHandler.GetRegisterHandler())
+	mux.HandleFunc("/post_register", appHandler.PostRegisterHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.AuthorizationEndpoint, "/get_authorize_user", appHandler.GetAuthorizeUser())
+	mux.HandleFunc("/post_authorize_user", appHandler.PostAuthorizeUser())
+	mux.HandleFunc("/list_app", appHandler.ListAppHandler())
+	mux.HandleFunc("/consents", consentHandler.ListConsentHandler())
+	mux.HandleFunc("/consents/revoke", consentHandler.RevokeConsentHandler())
+	mux.HandleFunc("/get_login", userHandler.GetLogin())
+	mux.HandleFunc("/post_login", userHandler.PostLogin())
+	mux.HandleFunc("/about", appHandler.AboutHandler())
+
+	discoveryHandler.HandleFunc(discoveryDelivery.RegistrationEndpoint, "/register", appHandler.RegisterHandler())
+	mux.HandleFunc("/register/", appHandler.ClientConfigurationHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.TokenEndpoint, "/api/oauth2/token", appHandler.OAuth2Handler())
+	discoveryHandler.HandleFunc(discoveryDelivery.IntrospectionEndpoint, "/api/oauth2/introspect", appHandler.IntrospectHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.RevocationEndpoint, "/api/oauth2/revoke", appHandler.RevokeHandler())
+	discoveryHandler.HandleFunc(discoveryDelivery.UserInfoEndpoint, "/userinfo", userHandler.UserInfo())
+	discoveryHandler.HandleFunc(discoveryDelivery.JWKSURI, "/.well-known/jwks.json", keyHandler.JWKSHandler())
 
-	http.HandleFunc("/api/oauth2/token", appHandler.OAuth2Handler())
+	mux.HandleFunc("/.well-known/oauth-authorization-server", discoveryHandler.AuthorizationServerHandler())
+	mux.HandleFunc("/.well-known/openid-configuration", discoveryHandler.OpenIDConfigurationHandler())
 
-	http.HandleFunc("/api/users", userHandler.CreateUser())
-	http.HandleFunc("/api/users/auth", userHandler.Auth())
-	http.HandleFunc("/api/users/me", middleware.JWTVerify(publicKey, userHandler.Me()))
+	mux.HandleFunc("/api/users", userHandler.CreateUser())
+	mux.HandleFunc("/api/users/auth", userHandler.Auth())
+	// only tokens of /api/users/auth are accepted, access tokens issued to clients have the client as audience
+	mux.HandleFunc("/api/users/me", middleware.JWTVerifyWithConfig(middleware.Config{
+		Keys:              keyManager,
+		RevocationChecker: denylist,
+		Issuer:            issuer,
+		Audience:          []string{issuer},
+		Realm:             "users",
+	}, userHandler.Me()))
 
 	log.Println("Listening...")
-	http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+}
+
+// runGateway serve gateway mode, every request is authenticated with the issuer before it reaches the upstream
+func runGateway(port int64, config gateway.Config) {
+	if len(config.Issuer) <= 0 {
+		fmt.Println("issuer is required in gateway mode")
+		os.Exit(1)
+	}
+
+	gw, err := gateway.New(context.Background(), config)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	log.Printf("Gateway for %s listening...", config.Upstream)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), gw))
 }
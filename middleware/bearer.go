@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RFC 6750 section 3.1 error codes
+const (
+	ErrorInvalidRequest    = "invalid_request"
+	ErrorInvalidToken      = "invalid_token"
+	ErrorInsufficientScope = "insufficient_scope"
+)
+
+var (
+	// ErrNoToken returned when request carries no bearer token
+	ErrNoToken = errors.New("no token provided")
+
+	// ErrMultipleTokens returned when request carries bearer token in more than one place
+	ErrMultipleTokens = errors.New("more than one method used for including an access token")
+)
+
+// BearerToken extract bearer token from Authorization header, or when allowParameter is true,
+// from access_token form body (POST) or query (GET) parameter, RFC 6750 section 2
+func BearerToken(req *http.Request, allowParameter bool) (string, error) {
+	var tokens []string
+
+	if header := req.Header.Get("Authorization"); len(header) > 0 {
+		tokenSlice := strings.SplitN(header, " ", 2)
+		if len(tokenSlice) < 2 || !strings.EqualFold(tokenSlice[0], "Bearer") || len(tokenSlice[1]) <= 0 {
+			return "", errors.New("malformed authorization header")
+		}
+		tokens = append(tokens, tokenSlice[1])
+	}
+
+	if allowParameter {
+		if req.Method == http.MethodPost && strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			if token := req.PostFormValue("access_token"); len(token) > 0 {
+				tokens = append(tokens, token)
+			}
+		}
+
+		if req.Method == http.MethodGet {
+			if token := req.URL.Query().Get("access_token"); len(token) > 0 {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	switch len(tokens) {
+	case 0:
+		return "", ErrNoToken
+	case 1:
+		return tokens[0], nil
+	default:
+		return "", ErrMultipleTokens
+	}
+}
+
+// WriteBearerError write RFC 6750 WWW-Authenticate challenge,
+// error code is omitted when the request carries no token at all
+func WriteBearerError(res http.ResponseWriter, status int, realm, code, description, scope string) {
+	challenge := fmt.Sprintf(`Bearer realm="%s"`, realm)
+	if len(code) > 0 {
+		challenge += fmt.Sprintf(`, error="%s"`, code)
+	}
+
+	if len(description) > 0 {
+		challenge += fmt.Sprintf(`, error_description="%s"`, strings.Replace(description, `"`, `'`, -1))
+	}
+
+	if len(scope) > 0 {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+
+	res.Header().Set("WWW-Authenticate", challenge)
+	res.WriteHeader(status)
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// UserIDHeader request header older handlers read the subject from,
+// copies sent by the client are stripped before the request reaches the handler
+const UserIDHeader = "userId"
+
+// contextKey private type, so other packages can not overwrite verified token
+type contextKey int
+
+const verifiedTokenKey contextKey = iota
+
+// verifiedToken private data structure
+type verifiedToken struct {
+	claims   *jwtGen.AccessTokenClaims
+	rawToken string
+}
+
+// NewContext return context carrying claims of verified access token
+func NewContext(ctx context.Context, claims *jwtGen.AccessTokenClaims, rawToken string) context.Context {
+	return context.WithValue(ctx, verifiedTokenKey, &verifiedToken{claims: claims, rawToken: rawToken})
+}
+
+// ClaimsFromContext return claims of verified access token
+func ClaimsFromContext(ctx context.Context) (*jwtGen.AccessTokenClaims, bool) {
+	token, ok := ctx.Value(verifiedTokenKey).(*verifiedToken)
+	if !ok {
+		return nil, false
+	}
+
+	return token.claims, true
+}
+
+// SubjectFromContext return sub claim, user id or client id of client credentials token
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || len(claims.Subject) <= 0 {
+		return "", false
+	}
+
+	return claims.Subject, true
+}
+
+// ClientIDFromContext return client_id claim, the client the token was issued to
+func ClientIDFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || len(claims.ClientID) <= 0 {
+		return "", false
+	}
+
+	return claims.ClientID, true
+}
+
+// ScopesFromContext return scopes granted to the token
+func ScopesFromContext(ctx context.Context) []string {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return claims.Scopes()
+}
+
+// TokenFromContext return the raw access token, for forwarding it to another api
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(verifiedTokenKey).(*verifiedToken)
+	if !ok {
+		return "", false
+	}
+
+	return token.rawToken, true
+}
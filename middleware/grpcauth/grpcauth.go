@@ -0,0 +1,126 @@
+// Package grpcauth verify access tokens of gRPC calls with the same keys and claim rules as the HTTP middleware
+package grpcauth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/middleware"
+)
+
+// Requirement struct
+// scopes a method requires, replacing AnyScopes and AllScopes of middleware.Config for that method
+type Requirement struct {
+	AnyScopes []string
+	AllScopes []string
+}
+
+// Config struct
+// Methods is keyed by full method name, e.g. /report.ReportService/GetReport,
+// methods not listed are checked with scopes of middleware.Config
+type Config struct {
+	middleware.Config
+	Methods map[string]Requirement
+}
+
+// UnaryServerInterceptor verify authorization metadata of unary call,
+// claims are read in the handler with middleware.ClaimsFromContext
+func UnaryServerInterceptor(config Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := config.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor verify authorization metadata of streaming call,
+// claims are read in the handler with middleware.ClaimsFromContext(stream.Context())
+func StreamServerInterceptor(config Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := config.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize verify token of the call against rules of method, return context carrying the claims
+func (c *Config) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := c.Config
+	if requirement, ok := c.Methods[fullMethod]; ok {
+		rules.AnyScopes = requirement.AnyScopes
+		rules.AllScopes = requirement.AllScopes
+	}
+
+	claims, err := rules.Verify(token)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return middleware.NewContext(ctx, claims, token), nil
+}
+
+// ClaimsFromContext return claims of verified access token, shortcut of middleware.ClaimsFromContext
+func ClaimsFromContext(ctx context.Context) (*jwtGen.AccessTokenClaims, bool) {
+	return middleware.ClaimsFromContext(ctx)
+}
+
+// bearerToken read token from authorization metadata, the call must carry exactly one
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, middleware.ErrNoToken.Error())
+	}
+
+	values := md.Get("authorization")
+	switch len(values) {
+	case 0:
+		return "", status.Error(codes.Unauthenticated, middleware.ErrNoToken.Error())
+	case 1:
+	default:
+		return "", status.Error(codes.Unauthenticated, "more than one authorization metadata")
+	}
+
+	tokenSlice := strings.SplitN(values[0], " ", 2)
+	if len(tokenSlice) < 2 || !strings.EqualFold(tokenSlice[0], "Bearer") || len(tokenSlice[1]) <= 0 {
+		return "", status.Error(codes.Unauthenticated, "malformed authorization metadata")
+	}
+
+	return tokenSlice[1], nil
+}
+
+// toStatus map rejected token to gRPC status, missing scope is PermissionDenied, anything else Unauthenticated
+func toStatus(err error) error {
+	if verifyErr, ok := err.(*middleware.VerifyError); ok && verifyErr.Code == middleware.ErrorInsufficientScope {
+		return status.Errorf(codes.PermissionDenied, "%s: %s", verifyErr.Description, verifyErr.Scope)
+	}
+
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// serverStream grpc.ServerStream with the verified context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context function
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,205 @@
+package grpcauth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/middleware"
+	"github.com/musobarlab/oauth2-go/middleware/grpcauth"
+)
+
+const (
+	testIssuer     = "http://localhost:9000"
+	reportMethod   = "/report.ReportService/GetReport"
+	updateMethod   = "/report.ReportService/UpdateReport"
+	watchMethod    = "/report.ReportService/WatchReports"
+	unlistedMethod = "/report.ReportService/ListReports"
+)
+
+func newKeys(t *testing.T) *key.Manager {
+	privateKey, err := key.GenerateKey("ES256")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	keys, err := key.NewManager(privateKey, time.Hour)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+
+	return keys
+}
+
+// newConfig require report.read for methods not listed, report.write for updateMethod
+func newConfig(keys *key.Manager) grpcauth.Config {
+	return grpcauth.Config{
+		Config: middleware.Config{
+			Keys:      keys,
+			Issuer:    testIssuer,
+			Audience:  []string{"client-1"},
+			AnyScopes: []string{"report.read"},
+		},
+		Methods: map[string]grpcauth.Requirement{
+			updateMethod: {AllScopes: []string{"report.write"}},
+		},
+	}
+}
+
+func token(t *testing.T, keys *key.Manager, scopes ...string) string {
+	result := <-jwtGen.NewJwtGenerator(keys, time.Minute).GenerateAccessToken(jwtGen.Claim{
+		Issuer:   testIssuer,
+		Audience: "client-1",
+		Subject:  "user-1",
+		ClientID: "client-1",
+		Scopes:   scopes,
+	})
+	if result.Error != nil {
+		t.Fatalf("generate access token: %v", result.Error)
+	}
+
+	return result.AccessToken.AccessToken
+}
+
+func incoming(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+// unary call method through the interceptor, return claims the handler read from its context
+func unary(ctx context.Context, config grpcauth.Config, method string) (*jwtGen.AccessTokenClaims, error) {
+	var claims *jwtGen.AccessTokenClaims
+	_, err := grpcauth.UnaryServerInterceptor(config)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		claims, _ = grpcauth.ClaimsFromContext(ctx)
+		return nil, nil
+	})
+
+	return claims, err
+}
+
+// testStream grpc.ServerStream of incoming context ctx
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+
+	if status.Code(err) != code {
+		t.Errorf("err = %v, want %s", err, code)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	keys := newKeys(t)
+	config := newConfig(keys)
+
+	claims, err := unary(incoming("authorization", "Bearer "+token(t, keys, "report.read")), config, reportMethod)
+	if err != nil {
+		t.Fatalf("unary: %v", err)
+	}
+
+	if claims == nil || claims.Subject != "user-1" || claims.Scope != "report.read" {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestUnaryServerInterceptorRejectsToken(t *testing.T) {
+	keys := newKeys(t)
+	config := newConfig(keys)
+	accessToken := token(t, keys, "report.read")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"no metadata", context.Background()},
+		{"no authorization", incoming("x-request-id", "1")},
+		{"not bearer", incoming("authorization", "Basic "+accessToken)},
+		{"empty bearer", incoming("authorization", "Bearer ")},
+		{"two authorization", incoming("authorization", "Bearer "+accessToken, "authorization", "Bearer "+accessToken)},
+		{"invalid token", incoming("authorization", "Bearer "+accessToken+"x")},
+		{"unknown key", incoming("authorization", "Bearer "+token(t, newKeys(t), "report.read"))},
+	}
+
+	for _, test := range tests {
+		claims, err := unary(test.ctx, config, reportMethod)
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: err = %v, want Unauthenticated", test.name, err)
+		}
+
+		if claims != nil {
+			t.Errorf("%s: handler was called", test.name)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorMethodRequirement(t *testing.T) {
+	keys := newKeys(t)
+	config := newConfig(keys)
+
+	reader := incoming("authorization", "Bearer "+token(t, keys, "report.read"))
+	writer := incoming("authorization", "Bearer "+token(t, keys, "report.write"))
+
+	_, err := unary(reader, config, updateMethod)
+	expectCode(t, err, codes.PermissionDenied)
+
+	// requirement of the method replaces scopes of middleware.Config
+	_, err = unary(writer, config, updateMethod)
+	expectCode(t, err, codes.OK)
+
+	_, err = unary(writer, config, unlistedMethod)
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = unary(reader, config, unlistedMethod)
+	expectCode(t, err, codes.OK)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	keys := newKeys(t)
+	config := newConfig(keys)
+	interceptor := grpcauth.StreamServerInterceptor(config)
+
+	var claims *jwtGen.AccessTokenClaims
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		claims, _ = grpcauth.ClaimsFromContext(stream.Context())
+		return nil
+	}
+
+	stream := &testStream{ctx: incoming("authorization", "Bearer "+token(t, keys, "report.read"))}
+	if err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: watchMethod}, handler); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if claims == nil || claims.Subject != "user-1" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if subject, _ := middleware.SubjectFromContext(stream.Context()); len(subject) > 0 {
+		t.Error("claims were added to the context of the original stream")
+	}
+
+	claims = nil
+	stream = &testStream{ctx: incoming("authorization", "Token abc")}
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: watchMethod}, handler)
+	expectCode(t, err, codes.Unauthenticated)
+
+	stream = &testStream{ctx: incoming("authorization", "Bearer "+token(t, keys, "profile"))}
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: watchMethod}, handler)
+	expectCode(t, err, codes.PermissionDenied)
+
+	if claims != nil {
+		t.Error("handler was called for rejected stream")
+	}
+}
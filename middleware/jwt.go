@@ -1,15 +1,30 @@
 package middleware
 
 import (
-	"crypto/rsa"
 	"net/http"
 	"strings"
 
 	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
 )
 
-// JWTVerify this middleware function for verifying accessToken from Authorization Header
-func JWTVerify(verifyKey *rsa.PublicKey, next http.Handler) http.HandlerFunc {
+// RevocationChecker interface
+// report whether a token (by its jti) has been revoked before it expires
+type RevocationChecker interface {
+	IsRevoked(jti string) bool
+}
+
+// JWTVerify this middleware function for verifying accessToken from Authorization Header, claims are read with ClaimsFromContext
+// keys can be *key.Manager of the authorization server or key.NewStaticKeySet for keys loaded from disk
+func JWTVerify(keys key.PublicKeyProvider, next http.Handler) http.HandlerFunc {
+	return JWTVerifyRevocable(keys, nil, next)
+}
+
+// JWTVerifyRevocable this middleware function for verifying accessToken from Authorization Header
+// and rejecting revoked token, revocation check is skipped when revocationChecker is nil
+func JWTVerifyRevocable(keys key.PublicKeyProvider, revocationChecker RevocationChecker, next http.Handler) http.HandlerFunc {
 
 	return func(res http.ResponseWriter, req *http.Request) {
 		accessToken := req.Header.Get("Authorization")
@@ -28,14 +43,22 @@ func JWTVerify(verifyKey *rsa.PublicKey, next http.Handler) http.HandlerFunc {
 			return
 		}
 		tokenString := tokenSlice[1]
-		token, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return verifyKey, nil
-		})
-
-		if claims, ok := token.Claims.(*jwt.StandardClaims); ok && token.Valid {
-			memberID := claims.Subject
-			req.Header.Add("userId", memberID)
-			next.ServeHTTP(res, req)
+		token, err := jwt.ParseWithClaims(tokenString, &jwtGen.AccessTokenClaims{}, jwtGen.AccessTokenKeyFunc(keys))
+
+		if token == nil {
+			http.Error(res, "Token is not valid", http.StatusUnauthorized)
+			return
+		}
+
+		if claims, ok := token.Claims.(*jwtGen.AccessTokenClaims); ok && token.Valid {
+			if revocationChecker != nil && revocationChecker.IsRevoked(claims.Id) {
+				http.Error(res, "Token revoked", http.StatusUnauthorized)
+				return
+			}
+
+			// identity is read from context, a userId header sent by the client must not look verified
+			req.Header.Del(UserIDHeader)
+			next.ServeHTTP(res, req.WithContext(NewContext(req.Context(), claims, tokenString)))
 		} else if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
 				http.Error(res, "Token is not valid", http.StatusUnauthorized)
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/musobarlab/oauth2-go/core/key"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+)
+
+// defaultRealm used in WWW-Authenticate challenge when Config has no Realm
+const defaultRealm = "api"
+
+// Config struct
+// rules an access token must satisfy besides a valid signature, empty Issuer and Audience are not checked,
+// token must carry at least one of AnyScopes and every one of AllScopes,
+// Leeway is the clock skew allowed when checking exp, nbf and iat,
+// Audience lists client ids whose tokens the api accepts, tokens of /api/users/auth have the issuer as audience
+type Config struct {
+	Keys              key.PublicKeyProvider
+	RevocationChecker RevocationChecker
+	Issuer            string
+	Audience          []string
+	AnyScopes         []string
+	AllScopes         []string
+	Leeway            time.Duration
+	Realm             string
+}
+
+// VerifyError struct
+// rejected access token, Code is RFC 6750 error code, Scope the scopes the token is missing
+type VerifyError struct {
+	Status      int
+	Code        string
+	Description string
+	Scope       string
+}
+
+// Error function
+func (e *VerifyError) Error() string {
+	return e.Description
+}
+
+// Verify parse access token and check it against the config rules
+func (c *Config) Verify(tokenString string) (*jwtGen.AccessTokenClaims, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &jwtGen.AccessTokenClaims{}, jwtGen.AccessTokenKeyFunc(c.Keys))
+	if err != nil || !token.Valid {
+		return nil, invalidToken("token is not valid")
+	}
+
+	claims, ok := token.Claims.(*jwtGen.AccessTokenClaims)
+	if !ok {
+		return nil, invalidToken("token is not valid")
+	}
+
+	now := time.Now()
+	leeway := int64(c.Leeway / time.Second)
+	if claims.ExpiresAt <= 0 || now.Unix() > claims.ExpiresAt+leeway {
+		return nil, invalidToken("token expired")
+	}
+
+	if claims.NotBefore > 0 && now.Unix()+leeway < claims.NotBefore {
+		return nil, invalidToken("token is not valid yet")
+	}
+
+	if claims.IssuedAt > 0 && now.Unix()+leeway < claims.IssuedAt {
+		return nil, invalidToken("token used before issued")
+	}
+
+	if len(c.Issuer) > 0 && claims.Issuer != c.Issuer {
+		return nil, invalidToken("token issuer is not accepted")
+	}
+
+	if len(c.Audience) > 0 && !contains(c.Audience, claims.Audience) {
+		return nil, invalidToken("token audience is not accepted")
+	}
+
+	if c.RevocationChecker != nil && c.RevocationChecker.IsRevoked(claims.Id) {
+		return nil, invalidToken("token revoked")
+	}
+
+	scopes := claims.Scopes()
+	if len(c.AnyScopes) > 0 && !containsAny(scopes, c.AnyScopes) {
+		return nil, insufficientScope(c.AnyScopes)
+	}
+
+	if len(c.AllScopes) > 0 && !containsAll(scopes, c.AllScopes) {
+		return nil, insufficientScope(c.AllScopes)
+	}
+
+	return claims, nil
+}
+
+// JWTVerifyWithConfig this middleware function for verifying accessToken from Authorization Header against config,
+// verified claims are put in request context, rejection is answered with RFC 6750 WWW-Authenticate challenge
+func JWTVerifyWithConfig(config Config, next http.Handler) http.HandlerFunc {
+	realm := config.Realm
+	if len(realm) <= 0 {
+		realm = defaultRealm
+	}
+
+	return func(res http.ResponseWriter, req *http.Request) {
+		tokenString, err := BearerToken(req, false)
+		if err == ErrNoToken {
+			WriteBearerError(res, http.StatusUnauthorized, realm, "", "", "")
+			return
+		}
+
+		if err != nil {
+			WriteBearerError(res, http.StatusBadRequest, realm, ErrorInvalidRequest, err.Error(), "")
+			return
+		}
+
+		claims, err := config.Verify(tokenString)
+		if err != nil {
+			verifyErr := err.(*VerifyError)
+			WriteBearerError(res, verifyErr.Status, realm, verifyErr.Code, verifyErr.Description, verifyErr.Scope)
+			return
+		}
+
+		req.Header.Del(UserIDHeader)
+		next.ServeHTTP(res, req.WithContext(NewContext(req.Context(), claims, tokenString)))
+	}
+}
+
+// RequireAnyScope this middleware function accept access token carrying at least one of scopes
+func RequireAnyScope(config Config, next http.Handler, scopes ...string) http.HandlerFunc {
+	config.AnyScopes = scopes
+	return JWTVerifyWithConfig(config, next)
+}
+
+// RequireAllScopes this middleware function accept access token carrying every one of scopes
+func RequireAllScopes(config Config, next http.Handler, scopes ...string) http.HandlerFunc {
+	config.AllScopes = scopes
+	return JWTVerifyWithConfig(config, next)
+}
+
+// invalidToken function
+func invalidToken(description string) *VerifyError {
+	return &VerifyError{Status: http.StatusUnauthorized, Code: ErrorInvalidToken, Description: description}
+}
+
+// insufficientScope function
+func insufficientScope(scopes []string) *VerifyError {
+	return &VerifyError{
+		Status:      http.StatusForbidden,
+		Code:        ErrorInsufficientScope,
+		Description: "token does not have the required scope",
+		Scope:       strings.Join(scopes, " "),
+	}
+}
+
+// contains function
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsAny function
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsAll function
+func containsAll(list, values []string) bool {
+	for _, v := range values {
+		if !contains(list, v) {
+			return false
+		}
+	}
+
+	return true
+}
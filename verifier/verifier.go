@@ -0,0 +1,249 @@
+// Package verifier verify access tokens issued by an oauth2-go server from another Go service.
+// Keys are read from the issuer jwks_uri found by discovery, so signing key rotation needs no redeploy.
+// Revocation is not seen by the verifier, keep access token age short or use introspection when it matters
+package verifier
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	discoveryModel "github.com/musobarlab/oauth2-go/core/discovery/model"
+	"github.com/musobarlab/oauth2-go/core/key"
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/middleware"
+)
+
+const (
+	defaultMinRefreshInterval = 10 * time.Second
+	defaultCacheTTL           = time.Hour
+	defaultHTTPTimeout        = 10 * time.Second
+)
+
+// ErrIssuerMismatch returned when discovery document names another issuer, OpenID Connect Discovery 1.0 section 4.3
+var ErrIssuerMismatch = errors.New("issuer in discovery document does not match")
+
+// Config struct
+// Issuer is the issuer URL of the server, Audience and Leeway are checked like middleware.Config,
+// JWKS is fetched again after CacheTTL, and at most once every MinRefreshInterval when a token has an unknown kid
+type Config struct {
+	Issuer             string
+	Audience           []string
+	Leeway             time.Duration
+	Realm              string
+	HTTPClient         *http.Client
+	CacheTTL           time.Duration
+	MinRefreshInterval time.Duration
+}
+
+// Verifier struct
+type Verifier struct {
+	config   Config
+	client   *http.Client
+	metadata discoveryModel.Metadata
+
+	refreshMutex sync.Mutex
+	mutex        sync.RWMutex
+	jwks         *key.JWKS
+	fetchedAt    time.Time
+}
+
+// New function for initializing Verifier, discovery metadata and JWKS are fetched before it returns
+func New(ctx context.Context, config Config) (*Verifier, error) {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if len(config.Issuer) <= 0 {
+		return nil, errors.New("issuer is required")
+	}
+
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultCacheTTL
+	}
+
+	if config.MinRefreshInterval <= 0 {
+		config.MinRefreshInterval = defaultMinRefreshInterval
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	v := &Verifier{config: config, client: client}
+	if err := v.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Metadata return discovery metadata of the issuer
+func (v *Verifier) Metadata() discoveryModel.Metadata {
+	return v.metadata
+}
+
+// Verify check signature and claims of access token, error is *middleware.VerifyError when the token is rejected
+func (v *Verifier) Verify(ctx context.Context, token string) (*jwtGen.AccessTokenClaims, error) {
+	config := v.middlewareConfig(ctx)
+	return config.Verify(token)
+}
+
+// Middleware this middleware function for verifying accessToken from Authorization Header,
+// claims are read with middleware.ClaimsFromContext
+func (v *Verifier) Middleware(next http.Handler) http.HandlerFunc {
+	return middleware.JWTVerifyWithConfig(v.middlewareConfig(context.Background()), next)
+}
+
+// RequireAnyScope this middleware function accept access token carrying at least one of scopes
+func (v *Verifier) RequireAnyScope(next http.Handler, scopes ...string) http.HandlerFunc {
+	return middleware.RequireAnyScope(v.middlewareConfig(context.Background()), next, scopes...)
+}
+
+// RequireAllScopes this middleware function accept access token carrying every one of scopes
+func (v *Verifier) RequireAllScopes(next http.Handler, scopes ...string) http.HandlerFunc {
+	return middleware.RequireAllScopes(v.middlewareConfig(context.Background()), next, scopes...)
+}
+
+// PublicKey return verification key with kid, implement key.PublicKeyProvider
+func (v *Verifier) PublicKey(kid string) (crypto.PublicKey, error) {
+	return v.publicKey(context.Background(), kid)
+}
+
+// middlewareConfig return claim rules of the verifier, keys are fetched with ctx
+func (v *Verifier) middlewareConfig(ctx context.Context) middleware.Config {
+	return middleware.Config{
+		Keys:     &contextKeys{ctx: ctx, verifier: v},
+		Issuer:   v.metadata.Issuer,
+		Audience: v.config.Audience,
+		Leeway:   v.config.Leeway,
+		Realm:    v.config.Realm,
+	}
+}
+
+// publicKey look kid up in cached JWKS, JWKS is fetched again when it is stale or does not know kid
+func (v *Verifier) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mutex.RLock()
+	jwks, fetchedAt := v.jwks, v.fetchedAt
+	v.mutex.RUnlock()
+
+	jwk, err := jwks.Key(kid)
+	if err == nil && time.Since(fetchedAt) < v.config.CacheTTL {
+		return jwk.PublicKey()
+	}
+
+	// unknown kid may be a key rotated in after the last fetch, but must not let every forged kid hit the issuer
+	if time.Since(fetchedAt) >= v.config.MinRefreshInterval {
+		if refreshErr := v.refresh(ctx); refreshErr != nil && err != nil {
+			return nil, refreshErr
+		}
+
+		v.mutex.RLock()
+		jwks = v.jwks
+		v.mutex.RUnlock()
+
+		jwk, err = jwks.Key(kid)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.PublicKey()
+}
+
+// discover fetch metadata from OpenID Connect discovery, or RFC 8414 metadata when the issuer has no OpenID configuration
+func (v *Verifier) discover(ctx context.Context) error {
+	var err error
+	for _, path := range []string{"/.well-known/openid-configuration", "/.well-known/oauth-authorization-server"} {
+		var metadata discoveryModel.Metadata
+		if err = v.getJSON(ctx, v.config.Issuer+path, &metadata); err != nil {
+			continue
+		}
+
+		if metadata.Issuer != v.config.Issuer {
+			return ErrIssuerMismatch
+		}
+
+		if len(metadata.JWKSURI) <= 0 {
+			return errors.New("discovery document has no jwks_uri")
+		}
+
+		v.metadata = metadata
+		return nil
+	}
+
+	return err
+}
+
+// refresh fetch JWKS, concurrent callers wait for a single fetch
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.refreshMutex.Lock()
+	defer v.refreshMutex.Unlock()
+
+	v.mutex.RLock()
+	fetchedAt := v.fetchedAt
+	v.mutex.RUnlock()
+
+	// another caller has just refreshed
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < v.config.MinRefreshInterval {
+		return nil
+	}
+
+	var jwks key.JWKS
+	err := v.getJSON(ctx, v.metadata.JWKSURI, &jwks)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	// failed fetch is rate limited too, cached keys keep working meanwhile
+	v.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+
+	v.jwks = &jwks
+	return nil
+}
+
+// getJSON function
+func (v *Verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: unexpected status %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// contextKeys key.PublicKeyProvider fetching keys with ctx of the request being verified
+type contextKeys struct {
+	ctx      context.Context
+	verifier *Verifier
+}
+
+// PublicKey function
+func (k *contextKeys) PublicKey(kid string) (crypto.PublicKey, error) {
+	return k.verifier.publicKey(k.ctx, kid)
+}
@@ -0,0 +1,179 @@
+package verifier_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	jwtGen "github.com/musobarlab/oauth2-go/core/user/token"
+	"github.com/musobarlab/oauth2-go/internal/oauth2test"
+	"github.com/musobarlab/oauth2-go/middleware"
+	"github.com/musobarlab/oauth2-go/verifier"
+)
+
+const jwksPath = "/.well-known/jwks.json"
+
+func newVerifier(t *testing.T, s *oauth2test.Server, config verifier.Config) *verifier.Verifier {
+	config.Issuer = s.URL
+	v, err := verifier.New(context.Background(), config)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	return v
+}
+
+// token sign access token of user-1 with report.read, tokenAge may be negative for expired token
+func token(t *testing.T, s *oauth2test.Server, audience string, tokenAge time.Duration) string {
+	return s.AccessToken(t, jwtGen.Claim{Audience: audience, Subject: "user-1", Scopes: []string{"report.read"}}, tokenAge)
+}
+
+// rotateTwice make the server sign with a key the verifier has not fetched, the key published as
+// pending at the first fetch becomes active after one rotation
+func rotateTwice(t *testing.T, s *oauth2test.Server) {
+	for n := 0; n < 2; n++ {
+		if err := s.Keys.Rotate(); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+	}
+}
+
+func verifyDescription(t *testing.T, err error) string {
+	verifyErr, ok := err.(*middleware.VerifyError)
+	if !ok {
+		t.Fatalf("expected *middleware.VerifyError, got %T %v", err, err)
+	}
+
+	return verifyErr.Description
+}
+
+func TestDiscovery(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{})
+	if v.Metadata().JWKSURI != s.URL+jwksPath {
+		t.Errorf("jwks_uri = %q", v.Metadata().JWKSURI)
+	}
+
+	if s.Requests(jwksPath) != 1 {
+		t.Errorf("jwks fetched %d times by New, want 1", s.Requests(jwksPath))
+	}
+}
+
+func TestDiscoveryFallbackToAuthorizationServerMetadata(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	s.Disable("/.well-known/openid-configuration")
+	v := newVerifier(t, s, verifier.Config{})
+	if v.Metadata().Issuer != s.URL {
+		t.Errorf("issuer = %q", v.Metadata().Issuer)
+	}
+
+	if s.Requests("/.well-known/oauth-authorization-server") != 1 {
+		t.Errorf("authorization server metadata fetched %d times, want 1", s.Requests("/.well-known/oauth-authorization-server"))
+	}
+}
+
+func TestIssuerMismatch(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	// same server under another name, its metadata names the 127.0.0.1 issuer
+	issuer := strings.Replace(s.URL, "127.0.0.1", "localhost", 1)
+	_, err := verifier.New(context.Background(), verifier.Config{Issuer: issuer})
+	if err != verifier.ErrIssuerMismatch {
+		t.Fatalf("err = %v, want ErrIssuerMismatch", err)
+	}
+}
+
+func TestVerifyCachesJWKS(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{Audience: []string{"client-1"}})
+	accessToken := token(t, s, "client-1", time.Minute)
+
+	for n := 0; n < 3; n++ {
+		claims, err := v.Verify(context.Background(), accessToken)
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		if claims.Subject != "user-1" || claims.Scope != "report.read" {
+			t.Errorf("claims = %+v", claims)
+		}
+	}
+
+	if s.Requests(jwksPath) != 1 {
+		t.Errorf("jwks fetched %d times, want 1", s.Requests(jwksPath))
+	}
+}
+
+func TestVerifyRefreshesJWKSOnUnknownKid(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{MinRefreshInterval: 10 * time.Millisecond})
+
+	rotateTwice(t, s)
+	accessToken := token(t, s, "client-1", time.Minute)
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := v.Verify(context.Background(), accessToken); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+
+	if s.Requests(jwksPath) != 2 {
+		t.Errorf("jwks fetched %d times, want 2", s.Requests(jwksPath))
+	}
+}
+
+func TestVerifyRefreshIsRateLimited(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{MinRefreshInterval: time.Hour})
+
+	rotateTwice(t, s)
+	accessToken := token(t, s, "client-1", time.Minute)
+
+	for n := 0; n < 3; n++ {
+		if _, err := v.Verify(context.Background(), accessToken); err == nil {
+			t.Fatal("token with unknown kid verified before refresh was allowed")
+		}
+	}
+
+	if s.Requests(jwksPath) != 1 {
+		t.Errorf("jwks fetched %d times, want 1", s.Requests(jwksPath))
+	}
+}
+
+func TestVerifyRejectsAudience(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{Audience: []string{"client-1"}})
+	_, err := v.Verify(context.Background(), token(t, s, "client-2", time.Minute))
+	if description := verifyDescription(t, err); description != "token audience is not accepted" {
+		t.Errorf("description = %q", description)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	s := oauth2test.NewServer(t)
+	defer s.Close()
+
+	v := newVerifier(t, s, verifier.Config{})
+	_, err := v.Verify(context.Background(), token(t, s, "client-1", -time.Minute))
+	if description := verifyDescription(t, err); description != "token expired" {
+		t.Errorf("description = %q", description)
+	}
+
+	v = newVerifier(t, s, verifier.Config{Leeway: 2 * time.Minute})
+	if _, err := v.Verify(context.Background(), token(t, s, "client-1", -time.Minute)); err != nil {
+		t.Errorf("token expired within leeway: %v", err)
+	}
+}